@@ -0,0 +1,111 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// splitArgs splits command line into arguments using shell-like rules.
+// Arguments are separated by whitespaces, single quotes preserve literal
+// value of all characters, double quotes preserve literal value of all
+// characters except backslash escaped double quote and backslash itself.
+// Unquoted backslash preserves literal value of the next character.
+// Unquoted # at the beginning of an argument starts a comment.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var inArg bool = false
+	rs := []rune(line)
+
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case r == '#' && !inArg:
+			return args, nil
+		case r == '\\':
+			if i+1 == len(rs) {
+				return nil, errors.New("unexpected end of line after '\\'")
+			}
+			i++
+			arg.WriteRune(rs[i])
+			inArg = true
+		case r == '\'':
+			j := i + 1
+			for j < len(rs) && rs[j] != '\'' {
+				j++
+			}
+			if j == len(rs) {
+				return nil, errors.New("unterminated single quote")
+			}
+			arg.WriteString(string(rs[i+1 : j]))
+			i = j
+			inArg = true
+		case r == '"':
+			j := i + 1
+			for ; j < len(rs) && rs[j] != '"'; j++ {
+				if rs[j] == '\\' && j+1 < len(rs) &&
+					(rs[j+1] == '"' || rs[j+1] == '\\') {
+					j++
+				}
+				arg.WriteRune(rs[j])
+			}
+			if j == len(rs) {
+				return nil, errors.New("unterminated double quote")
+			}
+			i = j
+			inArg = true
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
+
+// shellQuote quotes the string, if needed, so it can be safely used
+// as a single shell command argument.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+			r >= '0' && r <= '9' || strings.ContainsRune("-_./:,+=@%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,251 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/template"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+const (
+	BarI3bar   = "i3bar"
+	BarWaybar  = "waybar"
+	BarPolybar = "polybar"
+	BarTmux    = "tmux"
+)
+
+// Volume change step for mouse wheel clicks.
+const barVolumeStep = 5
+
+type BarCommand struct {
+}
+
+func NewBarCommand() BarCommand {
+	return BarCommand{}
+}
+
+func (c BarCommand) Name() string {
+	return "bar"
+}
+
+func (c BarCommand) Options() []*opt.Desc {
+	return []*opt.Desc{
+		{"", "protocol", opt.ArgString, "PROTOCOL",
+			"bar protocol: i3bar, waybar, polybar or tmux"},
+		reconnectOption(),
+		templateOption(),
+	}
+}
+
+func (c BarCommand) Args() (int, int) {
+	return 0, 0
+}
+
+func (c BarCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+func (c BarCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	proto := opts.StringOr("protocol", "")
+	switch proto {
+	case BarI3bar, BarWaybar, BarPolybar, BarTmux:
+	case "":
+		return &usageError{c}
+	default:
+		return fmt.Errorf("unsupported bar protocol: %s", proto)
+	}
+	t, err := parseTemplate(opts)
+	if err != nil {
+		return err
+	}
+
+	stream, err := openEventStream(env.Settings, ch, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	clicks := make(chan int)
+	if proto == BarI3bar {
+		env.Print("{\"version\":1,\"click_events\":true}\n[\n[]\n")
+		go readBarClicks(os.Stdin, clicks)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	w := &statusWatch{stream: stream}
+	if err := w.refresh(); err != nil {
+		return err
+	}
+	for {
+		if err := printBar(env, proto, w, t); err != nil {
+			return err
+		}
+
+		select {
+		case e := <-stream.C:
+			if e == nil {
+				return errConnLost
+			}
+			if e.Event() == EventDisconnected {
+				w.disconnected = true
+				continue
+			}
+		case b := <-clicks:
+			if w.disconnected {
+				continue
+			}
+			if err := barClick(env, stream.Chubby(), b); err != nil {
+				env.PrintError("%s", err)
+			}
+		case <-sigs:
+			return nil
+		}
+		if err := w.refresh(); err != nil {
+			return err
+		}
+	}
+}
+
+// barText returns text describing the status rendered with the template
+// or default one if template is nil.
+func barText(w *statusWatch, t *template.Template) (string, error) {
+	s := w.status
+	if w.disconnected {
+		return EventDisconnected, nil
+	}
+	if t != nil {
+		text, err := renderTemplate(t, s)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(text, "\n"), nil
+	}
+
+	switch s.State {
+	case chubby.StateStopped:
+		return "stopped", nil
+	case chubby.StatePlaying:
+		return fmt.Sprintf("%s - %s", s.Track.Artist, s.Track.Title), nil
+	default:
+		return fmt.Sprintf("[%s] %s - %s", s.State, s.Track.Artist,
+			s.Track.Title), nil
+	}
+}
+
+// printBar prints status update in the bar protocol format.
+func printBar(env *Env, proto string, w *statusWatch,
+	t *template.Template) error {
+
+	s := w.status
+	text, err := barText(w, t)
+	if err != nil {
+		return err
+	}
+
+	switch proto {
+	case BarI3bar:
+		b, err := json.Marshal([]map[string]string{{
+			"name":      "chubc",
+			"full_text": text,
+		}})
+		if err != nil {
+			return err
+		}
+		env.Printf(",%s\n", b)
+	case BarWaybar:
+		state := fmt.Sprintf("%s", s.State)
+		if w.disconnected {
+			state = EventDisconnected
+		}
+		tooltip := state
+		if !w.disconnected && s.State != chubby.StateStopped {
+			tooltip = fmt.Sprintf("%s\n%s\n%s (%d)", s.Track.Title,
+				s.Track.Artist, s.Track.Album, s.Track.Year)
+		}
+		return env.PrintJSON(map[string]interface{}{
+			"text":       text,
+			"tooltip":    tooltip,
+			"alt":        state,
+			"class":      state,
+			"percentage": s.Volume,
+		})
+	case BarPolybar:
+		// Colons in polybar action commands have to be escaped.
+		args := []string{shellQuote(prog())}
+		for _, a := range env.Settings.ConnectionArgs() {
+			args = append(args, shellQuote(a))
+		}
+		cmd := strings.ReplaceAll(strings.Join(args, " "), ":", "\\:")
+		env.Printf("%%{A1:%s pause:}%%{A3:%s next:}"+
+			"%%{A4:%s volume +%d:}%%{A5:%s volume -%d:}"+
+			"%s%%{A}%%{A}%%{A}%%{A}\n",
+			cmd, cmd, cmd, barVolumeStep, cmd, barVolumeStep, text)
+	case BarTmux:
+		env.Println(strings.ReplaceAll(text, "#", "##"))
+	}
+
+	return nil
+}
+
+// readBarClicks reads i3bar click events and sends clicked mouse buttons
+// to the channel.
+func readBarClicks(r io.Reader, clicks chan<- int) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimLeft(strings.TrimSpace(sc.Text()), ",")
+		var e struct {
+			Button int `json:"button"`
+		}
+		if json.Unmarshal([]byte(line), &e) == nil && e.Button != 0 {
+			clicks <- e.Button
+		}
+	}
+}
+
+// barClick executes command bound to the mouse button: left button
+// toggles pause, right one moves to the next track and wheel changes
+// volume.
+func barClick(env *Env, ch *chubby.Chubby, button int) error {
+	switch button {
+	case 1:
+		return runCommand(env, ch, NewPauseCommand())
+	case 3:
+		return runCommand(env, ch, NewNextCommand())
+	case 4:
+		return runCommand(env, ch, NewVolumeCommand(),
+			fmt.Sprintf("+%d", barVolumeStep))
+	case 5:
+		return runCommand(env, ch, NewVolumeCommand(),
+			fmt.Sprintf("-%d", barVolumeStep))
+	default:
+		return nil
+	}
+}
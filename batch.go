@@ -0,0 +1,134 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+type BatchCommand struct {
+}
+
+func NewBatchCommand() BatchCommand {
+	return BatchCommand{}
+}
+
+func (c BatchCommand) Name() string {
+	return "batch"
+}
+
+func (c BatchCommand) Options() []*opt.Desc {
+	return []*opt.Desc{}
+}
+
+func (c BatchCommand) Args() (int, int) {
+	return 0, 1
+}
+
+func (c BatchCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+func (c BatchCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	var in io.Reader = os.Stdin
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	orig := ch
+	defer func() {
+		if ch != orig {
+			ch.Close()
+		}
+	}()
+
+	sc := bufio.NewScanner(in)
+	berr := &batchError{}
+	for sc.Scan() {
+		berr.lines++
+		err := execBatchLine(env, ch, sc.Text())
+		if err != nil {
+			if !env.KeepGoing {
+				return fmt.Errorf("line %d: %w", berr.lines, err)
+			}
+			env.PrintError("line %d: %s", berr.lines, err)
+			berr.failed++
+			berr.last = err
+			ch = redial(env, ch, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if berr.failed > 0 {
+		return berr
+	}
+
+	return nil
+}
+
+// batchError is returned when some of lines failed in keep-going mode.
+// It wraps error of the last failed line, so exit status is the same as
+// if batch stopped on it.
+type batchError struct {
+	lines  int
+	failed int
+	last   error
+}
+
+func (e *batchError) Error() string {
+	return fmt.Sprintf("%d of %d lines failed", e.failed, e.lines)
+}
+
+func (e *batchError) Unwrap() error {
+	return e.last
+}
+
+func execBatchLine(env *Env, ch *chubby.Chubby, line string) error {
+	args, err := splitArgs(line)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	switch args[0] {
+	case "batch", "shell", "tui":
+		return fmt.Errorf("%s is not available in batch", args[0])
+	}
+	err = execCommand(env, ch, args)
+	var uerr *usageError
+	if errors.As(err, &uerr) {
+		printCommandUsage(env, uerr.cmd)
+	}
+
+	return err
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBatchErrors(t *testing.T) {
+	tests := []struct {
+		lines     string
+		keepGoing bool
+		err       string
+		status    int
+	}{
+		{"completion fish\ncompletion\ncompletion xsh\n", false,
+			"line 2: invalid completion command usage", ExitUsage},
+		{"completion\ncompletion xsh\n", true,
+			"2 of 2 lines failed", ExitFailure},
+		{"completion xsh\ncompletion\ncompletion fish\n", true,
+			"2 of 3 lines failed", ExitUsage},
+	}
+
+	for _, test := range tests {
+		p := filepath.Join(t.TempDir(), "batch")
+		err := os.WriteFile(p, []byte(test.lines), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env := &Env{
+			Settings:  &Settings{},
+			Output:    OutputText,
+			KeepGoing: test.keepGoing,
+			Stdout:    &bytes.Buffer{},
+			Stderr:    &bytes.Buffer{},
+		}
+		err = NewBatchCommand().Exec(env, nil, nil, []string{p})
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected %q, got %v", test.lines, test.err,
+				err)
+			continue
+		}
+		if s := exitStatus(err); s != test.status {
+			t.Errorf("%q: expected status %d, got %d", test.lines,
+				test.status, s)
+		}
+	}
+}
@@ -18,6 +18,11 @@
 package main
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
 	"github.com/vchimishuk/chubby"
 	"github.com/vchimishuk/opt"
 )
@@ -26,5 +31,132 @@ type Command interface {
 	Name() string
 	Options() []*opt.Desc
 	Args() (int, int)
-	Exec(c *chubby.Chubby, opts opt.Options, args []string) error
+	Exec(env *Env, c *chubby.Chubby, opts opt.Options, args []string) error
+}
+
+// Env is an environment commands are executed in. Commands use its
+// settings and write to its streams instead of the process ones, so
+// commands can be executed on several servers at once or on behalf of
+// daemon clients.
+type Env struct {
+	// Settings of the server commands are executed on.
+	Settings *Settings
+	// Output mode.
+	Output string
+	// Continue execution of the rest of commands if one fails.
+	KeepGoing bool
+	Stdout    io.Writer
+	Stderr    io.Writer
+	// Timer limiting execution time of the current command, nil if
+	// it is not limited.
+	deadline *time.Timer
+}
+
+// processEnv returns environment of the current process, which writes
+// to the standard streams.
+func processEnv() *Env {
+	return &Env{
+		Settings:  settings,
+		Output:    output,
+		KeepGoing: keepGoing,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}
+}
+
+func (e *Env) Print(args ...interface{}) {
+	fmt.Fprint(e.Stdout, args...)
+}
+
+func (e *Env) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(e.Stdout, format, args...)
+}
+
+func (e *Env) Println(args ...interface{}) {
+	fmt.Fprintln(e.Stdout, args...)
+}
+
+func (e *Env) PrintJSON(v interface{}) error {
+	return writeJSON(e.Stdout, v)
+}
+
+// PrintError prints error message prefixed with the program name or as
+// JSON object in JSON output mode.
+func (e *Env) PrintError(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if e.Output == OutputJSON {
+		writeJSON(e.Stderr, jsonError{msg})
+	} else {
+		fmt.Fprintf(e.Stderr, "%s: %s\n", prog(), msg)
+	}
+}
+
+// Terminal returns standard output file descriptor if it is a terminal.
+func (e *Env) Terminal() (int, bool) {
+	f, ok := e.Stdout.(*os.File)
+	if !ok || !isTerminal(int(f.Fd())) {
+		return 0, false
+	}
+
+	return int(f.Fd()), true
+}
+
+// CanPrompt returns true if user can be asked for input, i.e. standard
+// input and output are terminals and output mode is not JSON.
+func (e *Env) CanPrompt() bool {
+	_, ok := e.Terminal()
+
+	return ok && e.Output != OutputJSON && isTerminal(int(os.Stdin.Fd()))
+}
+
+// LocalCommand is implemented by commands which do not need connection
+// to the server. Such commands receive nil Chubby client.
+type LocalCommand interface {
+	Local() bool
+}
+
+func isLocal(cmd Command) bool {
+	l, ok := cmd.(LocalCommand)
+
+	return ok && l.Local()
+}
+
+// LongRunningCommand is implemented by commands which can run for
+// indefinite time with the given options. Such commands are not limited
+// by the timeout setting.
+type LongRunningCommand interface {
+	LongRunning(opts opt.Options) bool
+}
+
+func isLongRunning(cmd Command, opts opt.Options) bool {
+	l, ok := cmd.(LongRunningCommand)
+
+	return ok && l.LongRunning(opts)
+}
+
+// InteractiveCommand is implemented by commands which can ask user for
+// input with the given options, if it is possible.
+type InteractiveCommand interface {
+	Interactive(opts opt.Options) bool
+}
+
+func isInteractive(cmd Command, opts opt.Options) bool {
+	i, ok := cmd.(InteractiveCommand)
+
+	return ok && i.Interactive(opts)
+}
+
+// ArgsRewriter is implemented by commands which need to preprocess raw
+// command line arguments before they are parsed.
+type ArgsRewriter interface {
+	RewriteArgs(args []string) ([]string, error)
+}
+
+// notFoundError is returned when requested path or track does not exist.
+type notFoundError struct {
+	msg string
+}
+
+func (e *notFoundError) Error() string {
+	return e.msg
 }
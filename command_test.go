@@ -0,0 +1,85 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestRunChainEnv checks that commands write to the streams of their
+// environment, so several environments can be used at once.
+func TestRunChainEnv(t *testing.T) {
+	chain := [][]string{
+		{"completion", "xsh"},
+		{"completion", "fish"},
+	}
+	envs := make([]*Env, 8)
+	var wg sync.WaitGroup
+	for i := range envs {
+		env := &Env{
+			Settings:  &Settings{},
+			Output:    OutputText,
+			KeepGoing: i%2 == 0,
+			Stdout:    &bytes.Buffer{},
+			Stderr:    &bytes.Buffer{},
+		}
+		if i%4 < 2 {
+			env.Output = OutputJSON
+		}
+		envs[i] = env
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s := runChain(env, nil, chain); s != ExitFailure {
+				t.Errorf("exit status %d expected, got %d",
+					ExitFailure, s)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, env := range envs {
+		stdout := env.Stdout.(*bytes.Buffer).String()
+		stderr := env.Stderr.(*bytes.Buffer).String()
+		if env.KeepGoing != (stdout == fishCompletion) {
+			t.Errorf("env %d: unexpected output: %q", i, stdout)
+		}
+		msg := "command 1 (completion): unsupported shell: xsh"
+		expected := prog() + ": " + msg + "\n"
+		if env.Output == OutputJSON {
+			expected = `{"error":"` + msg + `"}` + "\n"
+		}
+		if stderr != expected {
+			t.Errorf("env %d: expected error %q, got %q", i, expected,
+				stderr)
+		}
+	}
+}
+
+func TestPrintCommandUsage(t *testing.T) {
+	var b bytes.Buffer
+	env := &Env{Stdout: &b}
+	printCommandUsage(env, NewCompletionCommand())
+	expected := "Usage: " + prog() + " completion [ARG]..."
+	if !strings.HasPrefix(b.String(), expected) {
+		t.Errorf("expected %q, got %q", expected, b.String())
+	}
+}
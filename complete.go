@@ -0,0 +1,262 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+// ArgCompleter is implemented by commands which can suggest values
+// for their positional arguments. n is a zero based index of the argument
+// being completed and prefix is its already typed part.
+type ArgCompleter interface {
+	CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error)
+}
+
+// optInfo returns option names and whether option expects an argument.
+func optInfo(d *opt.Desc) (string, string, bool) {
+	return d.Short, d.Long, d.Arg != opt.ArgNone
+}
+
+func findOpt(descs []*opt.Desc, word string) *opt.Desc {
+	for _, d := range descs {
+		short, long, _ := optInfo(d)
+		if (short != "" && word == "-"+short) ||
+			(long != "" && word == "--"+long) {
+			return d
+		}
+	}
+
+	return nil
+}
+
+func completeOpts(descs []*opt.Desc, prefix string) []string {
+	var cs []string
+	for _, d := range descs {
+		short, long, _ := optInfo(d)
+		if long != "" {
+			cs = append(cs, "--"+long)
+		}
+		if short != "" {
+			cs = append(cs, "-"+short)
+		}
+	}
+
+	return filterPrefix(cs, prefix)
+}
+
+// skipOpts returns index of the first non-option word. If the last
+// word is an option expecting an argument it is returned as well.
+func skipOpts(descs []*opt.Desc, words []string) (int, *opt.Desc) {
+	i := 0
+	for i < len(words) && strings.HasPrefix(words[i], "-") &&
+		words[i] != "-" {
+
+		d := findOpt(descs, words[i])
+		i++
+		if d == nil {
+			continue
+		}
+		if _, _, arg := optInfo(d); arg {
+			if i == len(words) {
+				return i, d
+			}
+			i++
+		}
+	}
+
+	return i, nil
+}
+
+// complete returns completion candidates for the last word of
+// the command line. words are command line arguments without program name.
+func complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := unquoteWord(words[len(words)-1])
+	prev := words[:len(words)-1]
+
+	gdescs := globalOptions()
+	i, gopt := skipOpts(gdescs, prev)
+	if gopt != nil {
+		return completeGlobalOpt(gopt, cur)
+	}
+	if i == len(prev) {
+		if strings.HasPrefix(cur, "-") {
+			return completeOpts(gdescs, cur)
+		}
+		names := append(commandNames(), "help")
+		slices.Sort(names)
+
+		return filterPrefix(names, cur)
+	}
+
+	cmd := command(prev[i])
+	if cmd == nil {
+		if prev[i] == "help" && i+1 == len(prev) {
+			return filterPrefix(commandNames(), cur)
+		}
+		return nil
+	}
+	args := prev[i+1:]
+	n := 0
+	for len(args) > 0 {
+		j, copt := skipOpts(cmd.Options(), args)
+		if copt != nil {
+			return nil
+		}
+		args = args[j:]
+		if len(args) > 0 {
+			args = args[1:]
+			n++
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		return completeOpts(cmd.Options(), cur)
+	}
+
+	ac, ok := cmd.(ArgCompleter)
+	if !ok {
+		return nil
+	}
+	var ch *chubby.Chubby
+	if !isLocal(cmd) {
+		gopts, _, err := opt.Parse(prev[:i], gdescs, true)
+		if err != nil {
+			return nil
+		}
+		settings, err = resolveSettings(gopts)
+		if err != nil {
+			return nil
+		}
+		ch, err = dial()
+		if err != nil {
+			return nil
+		}
+		defer ch.Close()
+	}
+	cs, err := ac.CompleteArg(ch, n, cur)
+	if err != nil {
+		return nil
+	}
+
+	return cs
+}
+
+func commandNames() []string {
+	var names []string
+	for _, c := range Commands {
+		names = append(names, c.Name())
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+func completeGlobalOpt(d *opt.Desc, prefix string) []string {
+	_, long, _ := optInfo(d)
+	switch long {
+	case "output":
+		return filterPrefix([]string{OutputJSON, OutputText}, prefix)
+	case "profile":
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for _, p := range cfg.Profiles() {
+			names = append(names, p.Name)
+		}
+
+		return filterPrefix(names, prefix)
+	}
+
+	return nil
+}
+
+// completeVFS returns VFS entries matching the path prefix.
+// Directory names are suffixed with a slash.
+func completeVFS(ch *chubby.Chubby, prefix string) ([]string, error) {
+	dir, base := path.Split(prefix)
+	entries, err := ch.List(vfsPath(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var cs []string
+	for _, e := range entries {
+		var name string
+		if e.IsDir() {
+			name = path.Base(e.Dir().Path) + "/"
+		} else {
+			name = path.Base(e.Track().Path)
+		}
+		if strings.HasPrefix(name, base) {
+			cs = append(cs, dir+name)
+		}
+	}
+
+	return cs, nil
+}
+
+func completePlaylists(ch *chubby.Chubby, prefix string) ([]string, error) {
+	plists, err := ch.Playlists()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pl := range plists {
+		names = append(names, pl.Name)
+	}
+	slices.Sort(names)
+
+	return filterPrefix(names, prefix), nil
+}
+
+func filterPrefix(ss []string, prefix string) []string {
+	var res []string
+	for _, s := range ss {
+		if strings.HasPrefix(s, prefix) {
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
+
+// unquoteWord removes shell quoting from the partially typed word.
+// Opening quote may be left unterminated.
+func unquoteWord(w string) string {
+	if strings.HasPrefix(w, "\"") || strings.HasPrefix(w, "'") {
+		if len(w) == 1 || w[len(w)-1] != w[0] {
+			w += w[:1]
+		}
+	}
+	args, err := splitArgs(w)
+	if err != nil || len(args) != 1 {
+		return w
+	}
+
+	return args[0]
+}
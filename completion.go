@@ -0,0 +1,116 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+const bashCompletion = `# bash completion for chubc
+_chubc() {
+	local IFS=$'\n'
+	local cs
+	cs=($(chubc __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+	COMPREPLY=("${cs[@]}")
+	compopt -o filenames
+	if [[ ${#cs[@]} -eq 1 && ${cs[0]} == */ ]]; then
+		compopt -o nospace
+	fi
+}
+complete -F _chubc chubc
+`
+
+const zshCompletion = `#compdef chubc
+# zsh completion for chubc
+_chubc() {
+	local -a args cs dirs others
+	local c
+	args=("${(@Q)words[2,CURRENT]}")
+	cs=("${(@f)$(chubc __complete "${args[@]}" 2>/dev/null)}")
+	for c in "${cs[@]}"; do
+		if [[ -z $c ]]; then
+			continue
+		elif [[ $c == */ ]]; then
+			dirs+=("$c")
+		else
+			others+=("$c")
+		fi
+	done
+	(( $#dirs )) && compadd -S '' -- "${dirs[@]}"
+	(( $#others )) && compadd -- "${others[@]}"
+}
+compdef _chubc chubc
+`
+
+const fishCompletion = `# fish completion for chubc
+function __chubc_complete
+	set -l tokens (commandline -opc)
+	set -l cur (commandline -ct)
+	chubc __complete $tokens[2..-1] "$cur" 2>/dev/null
+end
+complete -c chubc -f -a '(__chubc_complete)'
+`
+
+type CompletionCommand struct {
+}
+
+func NewCompletionCommand() CompletionCommand {
+	return CompletionCommand{}
+}
+
+func (c CompletionCommand) Name() string {
+	return "completion"
+}
+
+func (c CompletionCommand) Options() []*opt.Desc {
+	return []*opt.Desc{}
+}
+
+func (c CompletionCommand) Args() (int, int) {
+	return 1, 1
+}
+
+func (c CompletionCommand) Local() bool {
+	return true
+}
+
+func (c CompletionCommand) CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error) {
+	if n > 0 {
+		return nil, nil
+	}
+
+	return filterPrefix([]string{"bash", "fish", "zsh"}, prefix), nil
+}
+
+func (c CompletionCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	switch args[0] {
+	case "bash":
+		env.Print(bashCompletion)
+	case "fish":
+		env.Print(fishCompletion)
+	case "zsh":
+		env.Print(zshCompletion)
+	default:
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+type ConfigCommand struct {
+}
+
+func NewConfigCommand() ConfigCommand {
+	return ConfigCommand{}
+}
+
+func (c ConfigCommand) Name() string {
+	return "config"
+}
+
+func (c ConfigCommand) Options() []*opt.Desc {
+	return []*opt.Desc{}
+}
+
+func (c ConfigCommand) Args() (int, int) {
+	return 0, 0
+}
+
+func (c ConfigCommand) Local() bool {
+	return true
+}
+
+func (c ConfigCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	s := env.Settings
+	var profiles []string
+	for _, p := range s.Config.Profiles() {
+		profiles = append(profiles, p.Name)
+	}
+	vals := []struct {
+		name string
+		s    Setting
+	}{
+		{"profile", s.Profile},
+		{"host", s.Host},
+		{"port", s.Port},
+		{"format", s.Format},
+		{"output", s.Output},
+		{"timeout", s.Timeout},
+		{"retries", s.Retries},
+		{"proxy-command", s.ProxyCommand},
+		{"socks5", s.Socks5},
+	}
+
+	if env.Output == OutputJSON {
+		type jsonSetting struct {
+			Value  string `json:"value"`
+			Source string `json:"source"`
+		}
+		js := map[string]jsonSetting{}
+		for _, v := range vals {
+			js[v.name] = jsonSetting{v.s.Value, v.s.Source}
+		}
+		if profiles == nil {
+			profiles = []string{}
+		}
+
+		return env.PrintJSON(map[string]interface{}{
+			"config":   s.Config.Path,
+			"profiles": profiles,
+			"settings": js,
+		})
+	}
+
+	_, err := os.Stat(s.Config.Path)
+	if err != nil {
+		env.Printf("Config file: %s (not found)\n", s.Config.Path)
+	} else {
+		env.Printf("Config file: %s\n", s.Config.Path)
+	}
+	env.Printf("Profiles: %s\n", strings.Join(profiles, ", "))
+	env.Printf("\n")
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	for _, v := range vals {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.name, v.s.Value, v.s.Source)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Keys allowed in every known config file section kind. Top-level
+// key-value pairs, which do not belong to any section, have empty kind.
+var configKeys = map[string][]string{
+	"": {"profile", "host", "port", "format", "output", "timeout",
+		"retries", "proxy-command", "socks5"},
+	"profile": {"host", "port", "format", "output", "timeout", "retries",
+		"proxy-command", "socks5"},
+	// Named queries, any query name is allowed as a key.
+	"queries": nil,
+	// Event hooks, keys are event names or * for all events.
+	"hooks": nil,
+}
+
+type ConfigValue struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+type ConfigSection struct {
+	Kind   string
+	Name   string
+	Line   int
+	Values []ConfigValue
+}
+
+// Get returns the last value defined for the key in the section.
+func (s *ConfigSection) Get(key string) (ConfigValue, bool) {
+	for i := len(s.Values) - 1; i >= 0; i-- {
+		if s.Values[i].Key == key {
+			return s.Values[i], true
+		}
+	}
+
+	return ConfigValue{}, false
+}
+
+type Config struct {
+	Path     string
+	Sections []*ConfigSection
+}
+
+func configPath() string {
+	return filepath.Join(configHome(), "chubc", "config")
+}
+
+// loadConfig reads and parses configuration file. Missing configuration
+// file is not an error, empty configuration is returned in this case.
+func loadConfig() (*Config, error) {
+	p := configPath()
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{Path: p,
+			Sections: []*ConfigSection{{}}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseConfig(p, f)
+}
+
+func parseConfig(path string, r io.Reader) (*Config, error) {
+	cfg := &Config{Path: path, Sections: []*ConfigSection{{}}}
+	sect := cfg.Sections[0]
+	sc := bufio.NewScanner(r)
+	n := 0
+
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, configError(path, n,
+					"invalid section header")
+			}
+			fields := strings.Fields(line[1 : len(line)-1])
+			if len(fields) == 0 || len(fields) > 2 {
+				return nil, configError(path, n,
+					"invalid section header")
+			}
+			sect = &ConfigSection{Kind: fields[0], Line: n}
+			if len(fields) == 2 {
+				sect.Name = fields[1]
+			}
+			if err := validateSection(cfg, sect); err != nil {
+				return nil, configError(path, n, err.Error())
+			}
+			cfg.Sections = append(cfg.Sections, sect)
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, configError(path, n, "missing '='")
+		}
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+		if k == "" {
+			return nil, configError(path, n, "missing key")
+		}
+		keys := configKeys[sect.Kind]
+		if keys != nil && !slices.Contains(keys, k) {
+			return nil, configError(path, n,
+				fmt.Sprintf("unknown key %q", k))
+		}
+		// Query values are kept as is, since strings inside them
+		// are quoted too.
+		if sect.Kind != "queries" {
+			v = unquote(v)
+		}
+		if sect.Kind == "queries" {
+			_, err := ParseQuery(v)
+			var qerr *QueryError
+			if errors.As(err, &qerr) {
+				return nil, configError(path, n,
+					fmt.Sprintf("query %s: column %d: %s",
+						k, qerr.Column, qerr.Msg))
+			}
+		} else if k == "format" {
+			_, err := ParseFormat(v)
+			var ferr *FormatError
+			if errors.As(err, &ferr) {
+				return nil, configError(path, n,
+					fmt.Sprintf("format: column %d: %s",
+						ferr.Column, ferr.Msg))
+			}
+		}
+		sect.Values = append(sect.Values, ConfigValue{k, v, n})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func validateSection(cfg *Config, sect *ConfigSection) error {
+	if _, ok := configKeys[sect.Kind]; !ok || sect.Kind == "" {
+		return fmt.Errorf("unknown section %q", sect.Kind)
+	}
+	if sect.Kind == "profile" && sect.Name == "" {
+		return errors.New("profile name expected")
+	}
+	if sect.Kind != "profile" && sect.Name != "" {
+		return fmt.Errorf("unexpected section name %q", sect.Name)
+	}
+	if cfg.Section(sect.Kind, sect.Name) != nil {
+		return errors.New("duplicate section")
+	}
+
+	return nil
+}
+
+// Section returns configuration section by its kind and name or nil if
+// there is no such section.
+func (c *Config) Section(kind string, name string) *ConfigSection {
+	for _, s := range c.Sections {
+		if s.Kind == kind && s.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// Profiles returns all profile sections in the order of definition.
+func (c *Config) Profiles() []*ConfigSection {
+	var ps []*ConfigSection
+	for _, s := range c.Sections {
+		if s.Kind == "profile" {
+			ps = append(ps, s)
+		}
+	}
+
+	return ps
+}
+
+// Query returns named query defined in the configuration file.
+func (c *Config) Query(name string) (*Query, error) {
+	s := c.Section("queries", "")
+	if s == nil {
+		return nil, fmt.Errorf("query %q not found", name)
+	}
+	v, ok := s.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("query %q not found", name)
+	}
+
+	return ParseQuery(v.Value)
+}
+
+// Hooks returns commands of hooks configured for the event in the order
+// of definition.
+func (c *Config) Hooks(event string) []string {
+	s := c.Section("hooks", "")
+	if s == nil {
+		return nil
+	}
+	var hooks []string
+	for _, v := range s.Values {
+		if v.Key == event || v.Key == "*" {
+			hooks = append(hooks, v.Value)
+		}
+	}
+
+	return hooks
+}
+
+func configError(path string, line int, msg string) error {
+	return fmt.Errorf("%s:%d: %s", path, line, msg)
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+
+	return s
+}
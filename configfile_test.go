@@ -0,0 +1,131 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := parseConfig("config", strings.NewReader(`
+# Comment.
+; Another comment.
+host = "player.lan"
+port = 5115
+
+[profile kitchen]
+host = 'kitchen.lan'
+format = %a - %t
+timeout = 5s
+timeout = 3s
+
+[hooks]
+* = logger "event %e"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.Sections) != 3 {
+		t.Fatalf("3 sections expected, got %d", len(cfg.Sections))
+	}
+	expected := []ConfigValue{{"host", "player.lan", 4},
+		{"port", "5115", 5}}
+	if !slices.Equal(cfg.Sections[0].Values, expected) {
+		t.Errorf("unexpected top-level values: %v",
+			cfg.Sections[0].Values)
+	}
+
+	ps := cfg.Profiles()
+	if len(ps) != 1 || ps[0].Name != "kitchen" || ps[0].Line != 7 {
+		t.Fatalf("unexpected profiles: %v", ps)
+	}
+	if v, _ := ps[0].Get("host"); v.Value != "kitchen.lan" {
+		t.Errorf("unexpected host: %s", v.Value)
+	}
+	if v, _ := ps[0].Get("timeout"); v.Value != "3s" || v.Line != 11 {
+		t.Errorf("unexpected timeout: %s at line %d", v.Value, v.Line)
+	}
+	if _, ok := ps[0].Get("port"); ok {
+		t.Errorf("port is not defined in the profile")
+	}
+
+	hooks := cfg.Hooks("play")
+	if !slices.Equal(hooks, []string{`logger "event %e"`}) {
+		t.Errorf("unexpected hooks: %q", hooks)
+	}
+	if cfg.Section("profile", "office") != nil {
+		t.Errorf("unexpected office profile")
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{"host", "config:1: missing '='"},
+		{"= localhost", "config:1: missing key"},
+		{"\nhost = a\nname = b", `config:3: unknown key "name"`},
+		{"[profile a]\nprofile = b", `config:2: unknown key "profile"`},
+		{"[profile a", "config:1: invalid section header"},
+		{"[]", "config:1: invalid section header"},
+		{"[profile a b]", "config:1: invalid section header"},
+		{"[servers]", `config:1: unknown section "servers"`},
+		{"[profile]", "config:1: profile name expected"},
+		{"[queries all]", `config:1: unexpected section name "all"`},
+		{"[profile a]\n[profile a]", "config:2: duplicate section"},
+		{"format = %x", "config:1: format: column 2: " +
+			"unknown format specifier %x"},
+		{"[queries]\nq = year = x", "config:2: query q: column 8: " +
+			"number expected, got 'x'"},
+	}
+
+	for _, test := range tests {
+		_, err := parseConfig("config", strings.NewReader(test.config))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected %q, got %v", test.config,
+				test.err, err)
+		}
+	}
+}
+
+func TestConfigQueries(t *testing.T) {
+	cfg, err := parseConfig("config", strings.NewReader(`
+[queries]
+q = artist = "x" or artist = "y"
+re = title ~ "\d+"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, _ := cfg.Section("queries", "").Get("q")
+	if v.Value != `artist = "x" or artist = "y"` {
+		t.Errorf("unexpected query: %s", v.Value)
+	}
+	q, err := cfg.Query("re")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.Match(&QueryRecord{Title: "42"}) {
+		t.Errorf("%s: expected to match 42", q)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+// errTimeout is returned when connection or command is not completed
+// in time limited by the timeout setting.
+var errTimeout = errors.New("timed out")
+
+// connectError is returned when connection to the server can not be
+// established.
+type connectError struct {
+	err error
+}
+
+func (e *connectError) Error() string {
+	return e.err.Error()
+}
+
+func (e *connectError) Unwrap() error {
+	return e.err
+}
+
+// serverError is returned when the server rejects a request.
+type serverError struct {
+	err error
+}
+
+func (e *serverError) Error() string {
+	return e.err.Error()
+}
+
+func (e *serverError) Unwrap() error {
+	return e.err
+}
+
+// rejected marks error returned by the request as rejected by the server
+// unless it is caused by a connection failure.
+func rejected(err error) error {
+	if err == nil || isConnError(err) {
+		return err
+	}
+
+	return &serverError{err}
+}
+
+// notFound is like rejected but error is converted to notFoundError if
+// server reports that requested path does not exist. Server reports
+// errors as plain text without codes, so this is a heuristic matching
+// the message. It must be applied to errors of List and Play requests
+// only, see listDir and playPath, where the path is the only argument
+// which can be missing, so no other failure is reported as not found.
+func notFound(err error, p string) error {
+	if err == nil || isConnError(err) {
+		return err
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"not found", "no such file", "not exist"} {
+		if strings.Contains(msg, s) {
+			return &notFoundError{"path not found: " + p}
+		}
+	}
+
+	return &serverError{err}
+}
+
+// isConnError returns true if err is caused by a network failure.
+func isConnError(err error) bool {
+	var nerr net.Error
+
+	return errors.As(err, &nerr) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isTimeout returns true if err is caused by an exceeded timeout.
+func isTimeout(err error) bool {
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, errTimeout)
+}
+
+// redial returns a new connection to replace c if err means that c can
+// not be used anymore: it is closed after the command timeout or
+// connection to the server is lost. Otherwise, or if new connection can
+// not be established, c is returned.
+func redial(env *Env, c *chubby.Chubby, err error) *chubby.Chubby {
+	if c == nil || !isTimeout(err) && !isConnError(err) {
+		return c
+	}
+	nc, err := dialTo(env.Settings)
+	if err != nil {
+		return c
+	}
+
+	return nc
+}
+
+// connect establishes a new connection to the server specified by
+// current settings.
+func connect() (*chubby.Chubby, error) {
+	return connectTo(settings)
+}
+
+// connectTo establishes a new connection to the server specified by
+// settings. Failed connection is retried with exponential backoff as
+// many times as retries setting allows.
+func connectTo(s *Settings) (*chubby.Chubby, error) {
+	retries := s.RetriesNumber()
+	for attempt := 0; ; attempt++ {
+		c, err := dialTo(s)
+		if err == nil {
+			return c, nil
+		}
+		if attempt == retries {
+			return nil, &connectError{err}
+		}
+		time.Sleep(reconnectDelay(attempt))
+	}
+}
+
+// dial makes a single attempt to connect to the server specified by
+// current settings.
+func dial() (*chubby.Chubby, error) {
+	return dialTo(settings)
+}
+
+// dialTo makes a single attempt to connect to the server specified by
+// settings limited by the timeout setting.
+func dialTo(s *Settings) (*chubby.Chubby, error) {
+	c := &chubby.Chubby{}
+	timeout := s.TimeoutDuration()
+	if timeout == 0 {
+		err := connectAddr(c, s)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- connectAddr(c, s)
+	}()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	case <-t.C:
+		// Connection can still be established after the timeout.
+		go func() {
+			if <-done == nil {
+				c.Close()
+			}
+		}()
+		return nil, errTimeout
+	}
+}
+
+// execDeadline executes command limiting its execution time with
+// the timeout setting. Connection is closed if timeout is exceeded to
+// interrupt the pending request, it should be replaced using redial.
+// Command can suspend the timer while it waits for user input.
+func execDeadline(env *Env, c *chubby.Chubby, cmd Command, opts opt.Options,
+	args []string) error {
+
+	timeout := env.Settings.TimeoutDuration()
+	if c == nil || timeout == 0 || isLocal(cmd) || isLongRunning(cmd, opts) {
+		return cmd.Exec(env, c, opts, args)
+	}
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	denv := *env
+	denv.deadline = t
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Exec(&denv, c, opts, args)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-t.C:
+		c.Close()
+		return fmt.Errorf("%s: %w", cmd.Name(), errTimeout)
+	}
+}
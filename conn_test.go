@@ -0,0 +1,57 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestNotFound(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{nil, 0},
+		{errors.New("open /music/x: no such file or directory"),
+			ExitNotFound},
+		{errors.New("Path not found"), ExitNotFound},
+		{errors.New("/x does not exist"), ExitNotFound},
+		{errors.New("not a directory"), ExitRejected},
+		{fmt.Errorf("read: %w", io.EOF), ExitConnect},
+	}
+
+	for _, test := range tests {
+		err := notFound(test.err, "/x")
+		status := 0
+		if err != nil {
+			status = exitStatus(err)
+		}
+		if status != test.status {
+			t.Errorf("%v: expected status %d, got %d", test.err,
+				test.status, status)
+		}
+	}
+
+	err := notFound(errors.New("no such file"), "/music/x")
+	if err.Error() != "path not found: /music/x" {
+		t.Errorf("unexpected message: %s", err)
+	}
+}
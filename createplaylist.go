@@ -41,6 +41,6 @@ func (c CreatePlaylistCommand) Args() (int, int) {
 	return 1, 1
 }
 
-func (c CreatePlaylistCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.CreatePlaylist(args[0])
+func (c CreatePlaylistCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	return rejected(ch.CreatePlaylist(args[0]))
 }
@@ -0,0 +1,321 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+// daemonRequest is sent by client to the daemon to execute chained
+// commands. Client settings affecting commands output are passed along.
+type daemonRequest struct {
+	Args      []string `json:"args"`
+	Output    string   `json:"output"`
+	Format    string   `json:"format"`
+	KeepGoing bool     `json:"keep_going"`
+}
+
+// daemonResponse holds output and exit status of executed commands.
+type daemonResponse struct {
+	Stdout []byte `json:"stdout"`
+	Stderr []byte `json:"stderr"`
+	Status int    `json:"status"`
+}
+
+type DaemonCommand struct {
+}
+
+func NewDaemonCommand() DaemonCommand {
+	return DaemonCommand{}
+}
+
+func (c DaemonCommand) Name() string {
+	return "daemon"
+}
+
+func (c DaemonCommand) Options() []*opt.Desc {
+	return []*opt.Desc{reconnectOption()}
+}
+
+func (c DaemonCommand) Args() (int, int) {
+	return 0, 0
+}
+
+func (c DaemonCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+func (c DaemonCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	err := os.MkdirAll(runtimeDir(), 0700)
+	if err != nil {
+		return err
+	}
+	if err := checkRuntimeDir(); err != nil {
+		return err
+	}
+	p := daemonSocketPath(env.Settings)
+	if conn, err := net.Dial("unix", p); err == nil {
+		conn.Close()
+		return fmt.Errorf("daemon is already running: %s", p)
+	}
+	// Socket left by killed daemon.
+	os.Remove(p)
+	l, err := net.Listen("unix", p)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	if err := os.Chmod(p, 0600); err != nil {
+		return err
+	}
+
+	stream, err := openEventStream(env.Settings, ch, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	d := &daemon{stream: stream, settings: env.Settings}
+	defer os.Remove(daemonStatusPath(env.Settings))
+	if err := d.snapshot(); err != nil {
+		return err
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn)
+		}
+	}()
+
+	for {
+		select {
+		case e := <-stream.C:
+			if e == nil {
+				return errConnLost
+			}
+			if e.Event() == EventDisconnected {
+				os.Remove(daemonStatusPath(env.Settings))
+			} else if err := d.snapshot(); err != nil {
+				env.PrintError("%s", err)
+			}
+		case <-sigs:
+			return nil
+		}
+	}
+}
+
+// daemon executes commands received from clients over the single
+// server connection one at a time.
+type daemon struct {
+	stream   *eventStream
+	settings *Settings
+	mu       sync.Mutex
+}
+
+func (d *daemon) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var req daemonRequest
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &req)
+	}
+	if err != nil {
+		return
+	}
+	writeJSON(conn, d.exec(&req))
+}
+
+// exec executes chained commands with the client settings capturing
+// their output.
+func (d *daemon) exec(req *daemonRequest) *daemonResponse {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s := *d.settings
+	s.Format = Setting{req.Format, "client"}
+	var stdout, stderr bytes.Buffer
+	env := &Env{
+		Settings:  &s,
+		Output:    req.Output,
+		KeepGoing: req.KeepGoing,
+		Stdout:    &stdout,
+		Stderr:    &stderr,
+	}
+	resp := &daemonResponse{}
+	resp.Status = runChain(env, d.stream.Chubby(), splitChain(req.Args))
+	resp.Stdout = stdout.Bytes()
+	resp.Stderr = stderr.Bytes()
+	// Connection is closed when command times out.
+	if resp.Status == ExitTimeout || resp.Status == ExitConnect {
+		d.stream.Redial()
+	}
+
+	return resp
+}
+
+// snapshot saves current player status into the status file read by
+// shell prompt integrations. File is replaced atomically.
+func (d *daemon) snapshot() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.stream.Chubby().Status()
+	if err != nil {
+		return err
+	}
+	p := daemonStatusPath(d.settings)
+	f, err := os.CreateTemp(filepath.Dir(p), ".status-*")
+	if err != nil {
+		return err
+	}
+	err = writeJSON(f, newJSONStatus(s))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
+
+// daemonSocketPath returns path of the daemon socket for the server.
+func daemonSocketPath(s *Settings) string {
+	return filepath.Join(runtimeDir(), serverFileName(s)+".sock")
+}
+
+// daemonStatusPath returns path of the file with the server status
+// snapshot saved by the daemon.
+func daemonStatusPath(s *Settings) string {
+	return filepath.Join(runtimeDir(), serverFileName(s)+".status")
+}
+
+// dialDaemon connects to the daemon of the current server. Daemon is
+// not connected if its socket can be replaced by another user.
+func dialDaemon() (net.Conn, error) {
+	if err := checkRuntimeDir(); err != nil {
+		return nil, err
+	}
+	timeout := settings.TimeoutDuration()
+	if timeout == 0 {
+		return net.Dial("unix", daemonSocketPath(settings))
+	}
+
+	return net.DialTimeout("unix", daemonSocketPath(settings), timeout)
+}
+
+// forwardable returns true if all chained commands can be executed by
+// the daemon, i.e. they need server connection and complete quickly.
+func forwardable(chain [][]string) bool {
+	for _, a := range chain {
+		cmd, opts, err := parseCommand(a)
+		if err != nil || isLocal(cmd) || isLongRunning(cmd, opts) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// interactive returns true if any of chained commands can ask user for
+// input. Daemon has no access to the user terminal, so such commands are
+// not forwarded to it automatically.
+func interactive(chain [][]string) bool {
+	if !processEnv().CanPrompt() {
+		return false
+	}
+	for _, a := range chain {
+		cmd, opts, err := parseCommand(a)
+		if err == nil && isInteractive(cmd, opts) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseCommand parses options of the command. First argument is
+// a command name.
+func parseCommand(args []string) (Command, opt.Options, error) {
+	cmd := command(args[0])
+	if cmd == nil {
+		return nil, nil, fmt.Errorf("unknown command: %s", args[0])
+	}
+	args = args[1:]
+	if rw, ok := cmd.(ArgsRewriter); ok {
+		var err error
+		args, err = rw.RewriteArgs(args)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	opts, _, err := opt.Parse(args, cmd.Options(), false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cmd, opts, nil
+}
+
+// forwardDaemon executes command line arguments by the daemon, prints
+// commands output and returns their exit status.
+func forwardDaemon(conn net.Conn, args []string) (int, error) {
+	defer conn.Close()
+
+	err := writeJSON(conn, &daemonRequest{
+		Args:      args,
+		Output:    output,
+		Format:    settings.Format.Value,
+		KeepGoing: keepGoing,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var resp daemonResponse
+	err = json.NewDecoder(conn).Decode(&resp)
+	if err != nil {
+		return 0, err
+	}
+	os.Stdout.Write(resp.Stdout)
+	os.Stderr.Write(resp.Stderr)
+
+	return resp.Status, nil
+}
@@ -41,6 +41,14 @@ func (c DeletePlaylistCommand) Args() (int, int) {
 	return 1, 1
 }
 
-func (c DeletePlaylistCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.DeletePlaylist(args[0])
+func (c DeletePlaylistCommand) CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error) {
+	if n > 0 {
+		return nil, nil
+	}
+
+	return completePlaylists(ch, prefix)
+}
+
+func (c DeletePlaylistCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	return rejected(ch.DeletePlaylist(args[0]))
 }
@@ -18,7 +18,12 @@
 package main
 
 import (
-	"fmt"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/vchimishuk/chubby"
 	"github.com/vchimishuk/opt"
@@ -36,25 +41,117 @@ func (c EventsCommand) Name() string {
 }
 
 func (c EventsCommand) Options() []*opt.Desc {
-	return []*opt.Desc{}
+	return []*opt.Desc{
+		{"n", "count", opt.ArgInt, "N", "exit after N events printed"},
+		{"", "json", opt.ArgNone, "",
+			"print events as JSON objects with decoded fields"},
+		reconnectOption(),
+		templateOption(),
+		{"T", "timestamps", opt.ArgNone, "",
+			"prefix events with RFC3339 timestamps"},
+		{"t", "type", opt.ArgString, "NAME[,NAME]...",
+			"print events of given types only"},
+		{"", "until-event", opt.ArgString, "NAME",
+			"exit after event NAME arrives"},
+	}
 }
 
 func (c EventsCommand) Args() (int, int) {
 	return 0, 0
 }
 
-func (c EventsCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	chn, err := ch.Events(true)
+func (c EventsCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+func (c EventsCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	t, err := parseTemplate(opts)
+	if err != nil {
+		return err
+	}
+	var types []string
+	if opts.Has("type") {
+		types = strings.Split(opts.StringOr("type", ""), ",")
+	}
+	count := opts.IntOr("count", 0)
+	if opts.Has("count") && count <= 0 {
+		return errors.New("count must be positive")
+	}
+	until := opts.StringOr("until-event", "")
+	jsn := env.Output == OutputJSON || opts.Has("json")
+	stamps := opts.Has("timestamps")
+
+	s, err := openEventStream(env.Settings, ch, opts)
 	if err != nil {
 		return err
 	}
+	defer s.Close()
 
+	n := 0
 	for {
-		e := <-chn
+		e := <-s.C
 		if e == nil {
+			return errConnLost
+		}
+		now := time.Now()
+
+		if types == nil || slices.Contains(types, e.Event()) {
+			err := printEvent(env, e, now, t, jsn, stamps)
+			if err != nil {
+				return err
+			}
+			n++
+		}
+		if count > 0 && n >= count || until != "" && e.Event() == until {
 			return nil
 		}
+	}
+}
 
-		fmt.Printf("%s %s\n", e.Event(), e.Serialize())
+func printEvent(env *Env, e Event, now time.Time, t *template.Template,
+	jsn bool, stamps bool) error {
+
+	if t != nil {
+		return printTemplate(env.Stdout, t, e)
+	}
+	if jsn {
+		return env.PrintJSON(newJSONEvent(e, now))
 	}
+	if stamps {
+		env.Printf("%s ", now.Format(time.RFC3339))
+	}
+	env.Printf("%s %s\n", e.Event(), e.Serialize())
+
+	return nil
+}
+
+// decodeEventData decodes serialized event payload which consists of
+// `key: value` lines into separate fields. Keys are converted to lower
+// case with spaces replaced by underscores, integer values are decoded
+// as numbers. Nil is returned if payload has any other format.
+func decodeEventData(data string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil
+		}
+		k = strings.ReplaceAll(strings.ToLower(k), " ", "_")
+		v = strings.TrimSpace(v)
+		if n, err := strconv.Atoi(v); err == nil {
+			fields[k] = n
+		} else {
+			fields[k] = v
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return fields
 }
@@ -0,0 +1,173 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// History export formats.
+const (
+	ExportScrobblerLog = "scrobbler-log"
+	ExportListenBrainz = "listenbrainz"
+	ExportLastfmCSV    = "lastfm-csv"
+)
+
+// Maximum number of listens ListenBrainz accepts in a single import
+// payload.
+const listenBrainzMaxListens = 1000
+
+// exportHistory writes history records in the given format.
+func exportHistory(w io.Writer, format string, recs []*HistoryRecord) error {
+	switch format {
+	case ExportScrobblerLog:
+		return exportScrobblerLog(w, recs)
+	case ExportListenBrainz:
+		return exportListenBrainz(w, recs)
+	case ExportLastfmCSV:
+		return exportLastfmCSV(w, recs)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// exportScrobblerLog writes records in Audioscrobbler portable player
+// log format used by Rockbox. Skipped tracks are written with S rating.
+func exportScrobblerLog(w io.Writer, recs []*HistoryRecord) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("#AUDIOSCROBBLER/1.1\n#TZ/UTC\n#CLIENT/chubc\n")
+	for _, r := range recs {
+		rating := "L"
+		if r.Skipped {
+			rating = "S"
+		}
+		number := ""
+		if r.Number > 0 {
+			number = strconv.Itoa(r.Number)
+		}
+		fields := []string{
+			scrobblerLogField(r.Artist),
+			scrobblerLogField(r.Album),
+			scrobblerLogField(r.Title),
+			number,
+			strconv.Itoa(r.Length),
+			rating,
+			strconv.FormatInt(r.Time.Unix(), 10),
+			// MusicBrainz track ID is unknown.
+			"",
+		}
+		b.WriteString(strings.Join(fields, "\t") + "\n")
+	}
+
+	return b.Flush()
+}
+
+// scrobblerLogField replaces characters which can not be stored in
+// the log field with spaces.
+func scrobblerLogField(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+type listenBrainzPayload struct {
+	ListenType string          `json:"listen_type"`
+	Payload    []*listenBrainz `json:"payload"`
+}
+
+type listenBrainz struct {
+	ListenedAt int64                `json:"listened_at"`
+	Track      listenBrainzMetadata `json:"track_metadata"`
+}
+
+type listenBrainzMetadata struct {
+	Artist  string                 `json:"artist_name"`
+	Title   string                 `json:"track_name"`
+	Release string                 `json:"release_name,omitempty"`
+	Info    map[string]interface{} `json:"additional_info"`
+}
+
+// exportListenBrainz writes listened tracks as ListenBrainz import
+// payloads, one JSON object per line holding at most
+// listenBrainzMaxListens listens.
+func exportListenBrainz(w io.Writer, recs []*HistoryRecord) error {
+	var listens []*listenBrainz
+	for _, r := range recs {
+		if r.Skipped {
+			continue
+		}
+		info := map[string]interface{}{
+			"media_player":      "Chub",
+			"submission_client": "chubc",
+		}
+		if r.Number > 0 {
+			info["tracknumber"] = r.Number
+		}
+		if r.Length > 0 {
+			info["duration_ms"] = r.Length * 1000
+		}
+		listens = append(listens, &listenBrainz{
+			ListenedAt: r.Time.Unix(),
+			Track: listenBrainzMetadata{
+				Artist:  r.Artist,
+				Title:   r.Title,
+				Release: r.Album,
+				Info:    info,
+			},
+		})
+	}
+
+	for len(listens) > 0 {
+		n := min(len(listens), listenBrainzMaxListens)
+		err := writeJSON(w, &listenBrainzPayload{
+			ListenType: "import",
+			Payload:    listens[:n],
+		})
+		if err != nil {
+			return err
+		}
+		listens = listens[n:]
+	}
+
+	return nil
+}
+
+// exportLastfmCSV writes listened tracks as artist, album, title and
+// UTC date columns without a header, the layout Last.fm scrobble
+// exporters and importers use.
+func exportLastfmCSV(w io.Writer, recs []*HistoryRecord) error {
+	c := csv.NewWriter(w)
+	for _, r := range recs {
+		if r.Skipped {
+			continue
+		}
+		c.Write([]string{r.Artist, r.Album, r.Title,
+			r.Time.UTC().Format("02 Jan 2006 15:04")})
+	}
+	c.Flush()
+
+	return c.Error()
+}
@@ -0,0 +1,273 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exportRecords returns history records exported to the sample files
+// in testdata directory.
+func exportRecords() []*HistoryRecord {
+	return []*HistoryRecord{
+		{
+			Time:     time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC),
+			Artist:   "ZZ Top",
+			Album:    "XXX",
+			Title:    "Poke Chop Sandwich",
+			Number:   7,
+			Length:   202,
+			Listened: 202,
+		},
+		{
+			Time:     time.Date(2026, 1, 2, 10, 3, 0, 0, time.UTC),
+			Artist:   "Motörhead",
+			Album:    "Ace of\tSpades",
+			Title:    "Line\nBreak, \"Quoted\"",
+			Listened: 120,
+		},
+		{
+			Time:     time.Date(2026, 1, 2, 10, 5, 0, 0, time.UTC),
+			Artist:   "Black Sabbath",
+			Album:    "Paranoid",
+			Title:    "War Pigs",
+			Number:   1,
+			Length:   475,
+			Listened: 30,
+			Skipped:  true,
+		},
+	}
+}
+
+func TestExportScrobblerLog(t *testing.T) {
+	recs := exportRecords()
+	out := exportSample(t, ExportScrobblerLog, recs,
+		"history.scrobbler.log")
+
+	parsed, err := parseScrobblerLog(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Characters which can not be stored in the log are replaced
+	// with spaces.
+	recs[1].Album = "Ace of Spades"
+	recs[1].Title = "Line Break, \"Quoted\""
+	compareExported(t, recs, parsed, true)
+}
+
+func TestExportListenBrainz(t *testing.T) {
+	recs := exportRecords()
+	out := exportSample(t, ExportListenBrainz, recs,
+		"history.listenbrainz.jsonl")
+
+	parsed, err := parseListenBrainz(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareExported(t, recs[:2], parsed, false)
+}
+
+func TestExportLastfmCSV(t *testing.T) {
+	recs := exportRecords()
+	out := exportSample(t, ExportLastfmCSV, recs, "history.lastfm.csv")
+
+	parsed, err := parseLastfmCSV(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareExported(t, recs[:2], parsed, false)
+}
+
+func TestExportListenBrainzPayloads(t *testing.T) {
+	var recs []*HistoryRecord
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2*listenBrainzMaxListens+500; i++ {
+		recs = append(recs, &HistoryRecord{
+			Time:    start.Add(time.Duration(i) * time.Minute),
+			Artist:  "Artist",
+			Title:   fmt.Sprintf("Title %d", i),
+			Skipped: i%10 == 9,
+		})
+	}
+
+	var b bytes.Buffer
+	if err := exportHistory(&b, ExportListenBrainz, recs); err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int
+	sc := bufio.NewScanner(&b)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var p listenBrainzPayload
+		if err := json.Unmarshal(sc.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.ListenType != "import" {
+			t.Errorf("unexpected listen type: %s", p.ListenType)
+		}
+		sizes = append(sizes, len(p.Payload))
+	}
+	// Every tenth record is skipped and is not exported.
+	expected := []int{1000, 1000, 250}
+	if fmt.Sprint(sizes) != fmt.Sprint(expected) {
+		t.Errorf("expected payloads of %v listens, got %v", expected,
+			sizes)
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	err := exportHistory(io.Discard, "xspf", exportRecords())
+	if err == nil || err.Error() != "unsupported export format: xspf" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// exportSample exports records and compares result with the sample file.
+func exportSample(t *testing.T, format string, recs []*HistoryRecord,
+	sample string) []byte {
+
+	var b bytes.Buffer
+	if err := exportHistory(&b, format, recs); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(filepath.Join("testdata", sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), expected) {
+		t.Errorf("%s: output does not match the sample:\n%s", format,
+			b.String())
+	}
+
+	return b.Bytes()
+}
+
+// compareExported compares fields preserved by the export format.
+func compareExported(t *testing.T, expected []*HistoryRecord,
+	parsed []*HistoryRecord, skips bool) {
+
+	if len(parsed) != len(expected) {
+		t.Fatalf("%d records expected, got %d", len(expected),
+			len(parsed))
+	}
+	for i, e := range expected {
+		p := parsed[i]
+		if !p.Time.Equal(e.Time) || p.Artist != e.Artist ||
+			p.Album != e.Album || p.Title != e.Title {
+			t.Errorf("record %d: expected %+v, got %+v", i, e, p)
+		}
+		if skips && (p.Number != e.Number || p.Length != e.Length ||
+			p.Skipped != e.Skipped) {
+			t.Errorf("record %d: expected %+v, got %+v", i, e, p)
+		}
+	}
+}
+
+func parseScrobblerLog(r io.Reader) ([]*HistoryRecord, error) {
+	var recs []*HistoryRecord
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if strings.HasPrefix(sc.Text(), "#") {
+			continue
+		}
+		fs := strings.Split(sc.Text(), "\t")
+		if len(fs) != 8 {
+			return nil, fmt.Errorf("8 fields expected: %q", sc.Text())
+		}
+		rec := &HistoryRecord{Artist: fs[0], Album: fs[1], Title: fs[2]}
+		if fs[3] != "" {
+			n, err := strconv.Atoi(fs[3])
+			if err != nil {
+				return nil, err
+			}
+			rec.Number = n
+		}
+		l, err := strconv.Atoi(fs[4])
+		if err != nil {
+			return nil, err
+		}
+		rec.Length = l
+		if fs[5] != "L" && fs[5] != "S" {
+			return nil, fmt.Errorf("invalid rating: %s", fs[5])
+		}
+		rec.Skipped = fs[5] == "S"
+		ts, err := strconv.ParseInt(fs[6], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		rec.Time = time.Unix(ts, 0)
+		recs = append(recs, rec)
+	}
+
+	return recs, sc.Err()
+}
+
+func parseListenBrainz(r io.Reader) ([]*HistoryRecord, error) {
+	var recs []*HistoryRecord
+	dec := json.NewDecoder(r)
+	for {
+		var p listenBrainzPayload
+		err := dec.Decode(&p)
+		if err == io.EOF {
+			return recs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		for _, l := range p.Payload {
+			recs = append(recs, &HistoryRecord{
+				Time:   time.Unix(l.ListenedAt, 0),
+				Artist: l.Track.Artist,
+				Album:  l.Track.Release,
+				Title:  l.Track.Title,
+			})
+		}
+	}
+}
+
+func parseLastfmCSV(r io.Reader) ([]*HistoryRecord, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var recs []*HistoryRecord
+	for _, row := range rows {
+		t, err := time.Parse("02 Jan 2006 15:04", row[3])
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, &HistoryRecord{
+			Time:   t,
+			Artist: row[0],
+			Album:  row[1],
+			Title:  row[2],
+		})
+	}
+
+	return recs, nil
+}
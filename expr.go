@@ -0,0 +1,498 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query is a compiled library query expression like
+//
+//	artist ~ "sabbath" and year < 1980 and length > 5:00
+//
+// Expression consists of field comparisons combined with and, or, not
+// operators and parentheses. Supported comparison operators are
+// =, !=, <, <=, >, >= and ~, !~ for regular expression match.
+type Query struct {
+	src  string
+	root queryNode
+}
+
+// QueryRecord holds track fields query expression is evaluated against.
+type QueryRecord struct {
+	Path   string
+	File   string
+	Artist string
+	Album  string
+	Title  string
+	Year   int
+	Number int
+	// Track length in seconds.
+	Length int
+}
+
+// QueryError describes query syntax error.
+type QueryError struct {
+	Query string
+	// Position of the error in runes, starting from 1.
+	Column int
+	Msg    string
+}
+
+// Error returns error message along with the query and a marker
+// pointing at the offending column.
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("column %d: %s\n  %s\n  %s^", e.Column, e.Msg,
+		e.Query, strings.Repeat(" ", e.Column-1))
+}
+
+type queryFieldType int
+
+const (
+	queryString queryFieldType = iota
+	queryInt
+	queryDuration
+)
+
+var queryFields = map[string]queryFieldType{
+	"path":   queryString,
+	"file":   queryString,
+	"artist": queryString,
+	"album":  queryString,
+	"title":  queryString,
+	"year":   queryInt,
+	"number": queryInt,
+	"length": queryDuration,
+}
+
+func newQueryRecord(e vfsEntry) *QueryRecord {
+	return &QueryRecord{
+		Path:   e.Path,
+		File:   path.Base(e.Path),
+		Artist: e.Track.Artist,
+		Album:  e.Track.Album,
+		Title:  e.Track.Title,
+		Year:   e.Track.Year,
+		Number: e.Track.Number,
+		Length: seconds(e.Track.Length),
+	}
+}
+
+func newIndexQueryRecord(t *IndexTrack) *QueryRecord {
+	return &QueryRecord{
+		Path:   t.Path,
+		File:   path.Base(t.Path),
+		Artist: t.Artist,
+		Album:  t.Album,
+		Title:  t.Title,
+		Year:   t.Year,
+		Number: t.Number,
+		Length: t.Length,
+	}
+}
+
+func (r *QueryRecord) str(field string) string {
+	switch field {
+	case "path":
+		return r.Path
+	case "file":
+		return r.File
+	case "artist":
+		return r.Artist
+	case "album":
+		return r.Album
+	default:
+		return r.Title
+	}
+}
+
+func (r *QueryRecord) num(field string) int {
+	switch field {
+	case "year":
+		return r.Year
+	case "number":
+		return r.Number
+	default:
+		return r.Length
+	}
+}
+
+func (q *Query) String() string {
+	return q.src
+}
+
+func (q *Query) Match(r *QueryRecord) bool {
+	return q.root.eval(r)
+}
+
+type queryNode interface {
+	eval(r *QueryRecord) bool
+}
+
+type queryAnd struct {
+	left  queryNode
+	right queryNode
+}
+
+func (n *queryAnd) eval(r *QueryRecord) bool {
+	return n.left.eval(r) && n.right.eval(r)
+}
+
+type queryOr struct {
+	left  queryNode
+	right queryNode
+}
+
+func (n *queryOr) eval(r *QueryRecord) bool {
+	return n.left.eval(r) || n.right.eval(r)
+}
+
+type queryNot struct {
+	node queryNode
+}
+
+func (n *queryNot) eval(r *QueryRecord) bool {
+	return !n.node.eval(r)
+}
+
+type queryCmp struct {
+	field string
+	op    string
+	str   string
+	num   int
+	re    *regexp.Regexp
+}
+
+func (n *queryCmp) eval(r *QueryRecord) bool {
+	var c int
+	if queryFields[n.field] == queryString {
+		v := r.str(n.field)
+		switch n.op {
+		case "~":
+			return n.re.MatchString(v)
+		case "!~":
+			return !n.re.MatchString(v)
+		}
+		c = strings.Compare(fold(v), n.str)
+	} else {
+		v := r.num(n.field)
+		if v < n.num {
+			c = -1
+		} else if v > n.num {
+			c = 1
+		}
+	}
+
+	switch n.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+type queryTokenKind int
+
+const (
+	tokEOF queryTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	col  int
+}
+
+type queryParser struct {
+	src  string
+	toks []queryToken
+	pos  int
+}
+
+// ParseQuery compiles query expression.
+func ParseQuery(s string) (*Query, error) {
+	toks, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{src: s, toks: toks}
+	if p.peek().kind == tokEOF {
+		return nil, p.error(p.peek(), "empty query")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.error(t, fmt.Sprintf("unexpected %s", t))
+	}
+
+	return &Query{src: s, root: root}, nil
+}
+
+func (t queryToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+func (t queryToken) keyword(kw ...string) bool {
+	if t.kind != tokIdent && t.kind != tokOp {
+		return false
+	}
+	for _, k := range kw {
+		if strings.EqualFold(t.text, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.toks[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *queryParser) error(t queryToken, msg string) error {
+	return &QueryError{Query: p.src, Column: t.col, Msg: msg}
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &queryOr{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("and", "&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &queryAnd{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.peek().keyword("not", "!") {
+		p.next()
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNot{n}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	t := p.next()
+	if t.kind == tokLParen {
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokRParen {
+			return nil, p.error(r, fmt.Sprintf("')' expected, got %s", r))
+		}
+		return n, nil
+	}
+
+	if t.kind != tokIdent {
+		return nil, p.error(t, fmt.Sprintf("field name expected, got %s", t))
+	}
+	field := strings.ToLower(t.text)
+	ftype, ok := queryFields[field]
+	if !ok {
+		return nil, p.error(t, fmt.Sprintf("unknown field %q", t.text))
+	}
+
+	op := p.next()
+	if op.kind != tokOp || op.keyword("!", "&&", "||") {
+		return nil, p.error(op, fmt.Sprintf("comparison operator "+
+			"expected, got %s", op))
+	}
+	if ftype != queryString && (op.text == "~" || op.text == "!~") {
+		return nil, p.error(op, fmt.Sprintf("operator %s can not be "+
+			"used with %s field", op.text, field))
+	}
+
+	v := p.next()
+	n := &queryCmp{field: field, op: op.text}
+	switch ftype {
+	case queryString:
+		if v.kind != tokString && v.kind != tokIdent &&
+			v.kind != tokNumber {
+			return nil, p.error(v, fmt.Sprintf("value expected, "+
+				"got %s", v))
+		}
+		if op.text == "~" || op.text == "!~" {
+			_, err := regexp.Compile(v.text)
+			if err != nil {
+				return nil, p.error(v, fmt.Sprintf("invalid "+
+					"regular expression: %s", err))
+			}
+			n.re = regexp.MustCompile("(?i)" + v.text)
+		}
+		n.str = fold(v.text)
+	case queryInt:
+		num, err := strconv.Atoi(v.text)
+		if v.kind != tokNumber || err != nil {
+			return nil, p.error(v, fmt.Sprintf("number expected, "+
+				"got %s", v))
+		}
+		n.num = num
+	case queryDuration:
+		num, err := parseSeconds(v.text)
+		if v.kind != tokNumber || err != nil {
+			return nil, p.error(v, fmt.Sprintf("duration expected, "+
+				"got %s", v))
+		}
+		n.num = num
+	}
+
+	return n, nil
+}
+
+func lexQuery(s string) ([]queryToken, error) {
+	var toks []queryToken
+	rs := []rune(s)
+	errorAt := func(col int, msg string) error {
+		return &QueryError{Query: s, Column: col, Msg: msg}
+	}
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		col := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			toks = append(toks, queryToken{tokLParen, "(", col})
+			i++
+		case r == ')':
+			toks = append(toks, queryToken{tokRParen, ")", col})
+			i++
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(rs) && rs[j] != r; j++ {
+				// Only quote and backslash are escaped, so regular
+				// expression escapes are kept as is.
+				if rs[j] == '\\' && j+1 < len(rs) &&
+					(rs[j+1] == r || rs[j+1] == '\\') {
+					j++
+				}
+				b.WriteRune(rs[j])
+			}
+			if j == len(rs) {
+				return nil, errorAt(col, "unterminated string")
+			}
+			toks = append(toks, queryToken{tokString, b.String(), col})
+			i = j + 1
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == ':') {
+				j++
+			}
+			toks = append(toks, queryToken{tokNumber,
+				string(rs[i:j]), col})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) ||
+				unicode.IsDigit(rs[j]) || rs[j] == '_' ||
+				rs[j] == '-' || rs[j] == '.') {
+				j++
+			}
+			toks = append(toks, queryToken{tokIdent,
+				string(rs[i:j]), col})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{"!=", "!~", "<=", ">=", "&&",
+				"||", "=", "~", "<", ">", "!"} {
+				if strings.HasPrefix(string(rs[i:]), o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, errorAt(col,
+					fmt.Sprintf("unexpected character %q", r))
+			}
+			toks = append(toks, queryToken{tokOp, op, col})
+			i += len(op)
+		}
+	}
+	toks = append(toks, queryToken{tokEOF, "", len(rs) + 1})
+
+	return toks, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQueryMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		rec   QueryRecord
+		match bool
+	}{
+		{`artist = "zz top"`, QueryRecord{Artist: "ZZ Top"}, true},
+		{`artist = motorhead`, QueryRecord{Artist: "Motörhead"}, true},
+		{`artist != motorhead`, QueryRecord{Artist: "Motörhead"}, false},
+		{`artist ~ "a\.b"`, QueryRecord{Artist: "a.b"}, true},
+		{`artist ~ "a\.b"`, QueryRecord{Artist: "axb"}, false},
+		{`title ~ "^\d+$"`, QueryRecord{Title: "1999"}, true},
+		{`title ~ "^\d+$"`, QueryRecord{Title: "dd"}, false},
+		{`title ~ "a\\b"`, QueryRecord{Title: `a\b`}, true},
+		{`title = "say \"hi\""`, QueryRecord{Title: `Say "Hi"`}, true},
+		{`title = 'it\'s'`, QueryRecord{Title: "It's"}, true},
+		{`title !~ sabbath`, QueryRecord{Title: "Black Sabbath"}, false},
+		{`year < 1980 and length > 5:00`,
+			QueryRecord{Year: 1970, Length: 301}, true},
+		{`year < 1980 && length > 5:00`,
+			QueryRecord{Year: 1970, Length: 300}, false},
+		{`number >= 2 or year = 1999`, QueryRecord{Year: 1999}, true},
+		{`not (artist = abba || artist = queen)`,
+			QueryRecord{Artist: "Queen"}, false},
+		{`!artist = abba`, QueryRecord{Artist: "Queen"}, true},
+		{`file = "01.flac" and path ~ "^/zz"`,
+			QueryRecord{Path: "/ZZ Top/01.flac", File: "01.flac"}, true},
+	}
+
+	for _, test := range tests {
+		q, err := ParseQuery(test.query)
+		if err != nil {
+			t.Errorf("%s: %s", test.query, err)
+			continue
+		}
+		if m := q.Match(&test.rec); m != test.match {
+			t.Errorf("%s: %+v: expected %t, got %t", test.query,
+				test.rec, test.match, m)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query  string
+		column int
+		msg    string
+	}{
+		{``, 1, "empty query"},
+		{`artist`, 7, "comparison operator expected, got end of query"},
+		{`foo = 1`, 1, `unknown field "foo"`},
+		{`= 1`, 1, "field name expected, got '='"},
+		{`year = abc`, 8, "number expected, got 'abc'"},
+		{`length > 1:xx`, 10, "duration expected, got '1:'"},
+		{`artist = "abc`, 10, "unterminated string"},
+		{`(artist = a`, 12, "')' expected, got end of query"},
+		{`year ~ 1`, 6, "operator ~ can not be used with year field"},
+		{`artist = a b`, 12, "unexpected 'b'"},
+		{`artist = a $`, 12, `unexpected character '$'`},
+		{`artist and`, 8, "comparison operator expected, got 'and'"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseQuery(test.query)
+		var qerr *QueryError
+		if !errors.As(err, &qerr) {
+			t.Errorf("%s: QueryError expected, got %v", test.query, err)
+			continue
+		}
+		if qerr.Column != test.column || qerr.Msg != test.msg {
+			t.Errorf("%s: expected column %d: %s, got column %d: %s",
+				test.query, test.column, test.msg, qerr.Column,
+				qerr.Msg)
+		}
+	}
+
+	_, err := ParseQuery(`artist ~ "("`)
+	var qerr *QueryError
+	if !errors.As(err, &qerr) || qerr.Column != 10 {
+		t.Errorf("invalid regular expression error expected at "+
+			"column 10, got %v", err)
+	}
+}
+
+func TestQueryErrorMarker(t *testing.T) {
+	_, err := ParseQuery(`year = abc`)
+	expected := "column 8: number expected, got 'abc'\n" +
+		"  year = abc\n" +
+		"         ^"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/vchimishuk/opt"
+)
+
+// fanOutResult holds output of chained commands executed on a single
+// server.
+type fanOutResult struct {
+	server string
+	stdout []byte
+	stderr []byte
+	errors []string
+	status int
+}
+
+type jsonFanOutResult struct {
+	Server string            `json:"server"`
+	Status int               `json:"status"`
+	Output []json.RawMessage `json:"output"`
+	Errors []string          `json:"errors,omitempty"`
+	Stderr string            `json:"stderr,omitempty"`
+}
+
+// fanOutServers returns names of the profiles selected with --all or
+// --servers options.
+func fanOutServers(opts opt.Options) ([]string, error) {
+	if opts.Has("all") && opts.Has("servers") {
+		return nil, errors.New("--all and --servers can not be used " +
+			"together")
+	}
+	var names []string
+	if opts.Has("all") {
+		for _, p := range settings.Config.Profiles() {
+			names = append(names, p.Name)
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no profiles defined in %s",
+				settings.Config.Path)
+		}
+	} else {
+		for _, n := range strings.Split(opts.StringOr("servers", ""), ",") {
+			if n == "" {
+				continue
+			}
+			if settings.Config.Section("profile", n) == nil {
+				return nil, fmt.Errorf("profile %q not found in %s",
+					n, settings.Config.Path)
+			}
+			names = append(names, n)
+		}
+		if len(names) == 0 {
+			return nil, errors.New("no servers given")
+		}
+	}
+	if opts.Has("host") || opts.Has("port") {
+		return nil, errors.New("--host and --port can not be used " +
+			"with several servers")
+	}
+
+	return names, nil
+}
+
+// fanOut executes chained commands on the servers concurrently, prints
+// their output grouped by server and returns process exit status.
+// Every server has its own connection and settings resolved from its
+// profile, so unreachable server fails after its own timeout without
+// blocking the rest. Commands write to the buffers of their server
+// environment, which are printed after all servers are done.
+func fanOut(opts opt.Options, servers []string, chain [][]string) int {
+	results := make([]*fanOutResult, len(servers))
+	var wg sync.WaitGroup
+	for i, name := range servers {
+		r := &fanOutResult{server: name}
+		results[i] = r
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := resolveProfileSettings(opts, r.server)
+			if err != nil {
+				r.fail(err)
+				return
+			}
+			c, err := connectTo(s)
+			if err != nil {
+				r.fail(fmt.Errorf("unable to connect to remote "+
+					"host: %w", err))
+				return
+			}
+			defer func() {
+				c.Close()
+			}()
+
+			var stdout, stderr bytes.Buffer
+			env := &Env{
+				Settings:  s,
+				Output:    output,
+				KeepGoing: keepGoing,
+				Stdout:    &stdout,
+				Stderr:    &stderr,
+			}
+			for i, a := range chain {
+				err := execCommand(env, c, a)
+				var uerr *usageError
+				if errors.As(err, &uerr) {
+					printCommandUsage(env, uerr.cmd)
+				}
+				if err != nil && len(chain) > 1 {
+					err = fmt.Errorf("command %d (%s): %w",
+						i+1, a[0], err)
+				}
+				if err != nil {
+					r.fail(err)
+					if !keepGoing {
+						break
+					}
+					c = redial(env, c, err)
+				}
+			}
+			r.stdout = stdout.Bytes()
+			r.stderr = stderr.Bytes()
+		}()
+	}
+	wg.Wait()
+
+	return printFanOut(results)
+}
+
+func (r *fanOutResult) fail(err error) {
+	r.errors = append(r.errors, err.Error())
+	r.status = exitStatus(err)
+}
+
+// printFanOut prints results and returns summary exit status: zero if
+// commands succeeded on all servers, status of the failure if they
+// failed on all servers with the same status and ExitPartial otherwise.
+func printFanOut(results []*fanOutResult) int {
+	if output == OutputJSON {
+		var jrs []*jsonFanOutResult
+		for _, r := range results {
+			jrs = append(jrs, newJSONFanOutResult(r))
+		}
+		writeJSON(os.Stdout, jrs)
+	} else {
+		first := true
+		for _, r := range results {
+			if len(r.stdout) > 0 {
+				if !first {
+					fmt.Println()
+				}
+				first = false
+				fmt.Printf("==> %s <==\n", r.server)
+				os.Stdout.Write(r.stdout)
+			}
+			os.Stderr.Write(r.stderr)
+			for _, e := range r.errors {
+				printError("%s: %s", r.server, e)
+			}
+		}
+	}
+
+	status := results[0].status
+	for _, r := range results[1:] {
+		if r.status != status {
+			return ExitPartial
+		}
+	}
+
+	return status
+}
+
+// newJSONFanOutResult converts result into JSON object. Commands print
+// JSON values in JSON output mode, they are included as is, any other
+// output is included as a string. Standard error output of commands,
+// such as their warnings, is included as a string too.
+func newJSONFanOutResult(r *fanOutResult) *jsonFanOutResult {
+	jr := &jsonFanOutResult{
+		Server: r.server,
+		Status: r.status,
+		Output: []json.RawMessage{},
+		Errors: r.errors,
+		Stderr: string(r.stderr),
+	}
+	dec := json.NewDecoder(bytes.NewReader(r.stdout))
+	for {
+		var v json.RawMessage
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			s, _ := json.Marshal(string(r.stdout))
+			jr.Output = []json.RawMessage{s}
+			break
+		}
+		jr.Output = append(jr.Output, v)
+	}
+
+	return jr
+}
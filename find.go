@@ -0,0 +1,364 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+	"github.com/vchimishuk/opt"
+)
+
+type FindCommand struct {
+}
+
+func NewFindCommand() FindCommand {
+	return FindCommand{}
+}
+
+func (c FindCommand) Name() string {
+	return "find"
+}
+
+func (c FindCommand) Options() []*opt.Desc {
+	return []*opt.Desc{
+		{"0", "", opt.ArgNone, "",
+			"separate items with NUL character instead of newline"},
+		{"", "album", opt.ArgString, "PATTERN", "match album"},
+		{"", "artist", opt.ArgString, "PATTERN", "match artist"},
+		{"", "exec", opt.ArgString, "COMMAND",
+			"execute command for every matched item"},
+		{"f", "", opt.ArgString, "FORMAT", "item format"},
+		{"", "max-length", opt.ArgString, "TIME",
+			"match tracks not longer than TIME"},
+		{"", "min-length", opt.ArgString, "TIME",
+			"match tracks not shorter than TIME"},
+		{"q", "query", opt.ArgString, "EXPR",
+			"match query expression"},
+		{"r", "regex", opt.ArgNone, "",
+			"treat patterns as regular expressions"},
+		{"", "title", opt.ArgString, "PATTERN", "match title"},
+		{"", "type", opt.ArgString, "d|f",
+			"match directories or files only"},
+		{"", "year", opt.ArgString, "[FROM]-[TO]", "match year range"},
+	}
+}
+
+func (c FindCommand) Args() (int, int) {
+	return 1, 1
+}
+
+func (c FindCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+// RewriteArgs converts find(1) style `-exec COMMAND [ARG]... ;`
+// into --exec option with a single shell-quoted argument.
+func (c FindCommand) RewriteArgs(args []string) ([]string, error) {
+	var res []string
+	for i := 0; i < len(args); i++ {
+		if args[i] != "-exec" {
+			res = append(res, args[i])
+			continue
+		}
+		j := i + 1
+		for j < len(args) && args[j] != ";" {
+			j++
+		}
+		if j == len(args) || j == i+1 {
+			return nil, errors.New("-exec: command terminated " +
+				"with ';' expected")
+		}
+		var cmd []string
+		for _, a := range args[i+1 : j] {
+			cmd = append(cmd, shellQuote(a))
+		}
+		res = append(res, "--exec", strings.Join(cmd, " "))
+		i = j
+	}
+
+	return res, nil
+}
+
+func (c FindCommand) CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error) {
+	if n > 0 {
+		return nil, nil
+	}
+
+	return completeVFS(ch, prefix)
+}
+
+func (c FindCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	f, err := newFindFilter(opts)
+	if err != nil {
+		return err
+	}
+	var execArgs []string
+	if opts.Has("exec") {
+		execArgs, err = splitArgs(opts.StringOr("exec", ""))
+		if err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+	}
+	lf, err := ParseFormat(opts.StringOr("f", "%p%f%/"))
+	if err != nil {
+		return fmt.Errorf("format: %w", err)
+	}
+	sep := "\n"
+	if opts.Has("0") {
+		sep = "\x00"
+	}
+
+	return walk(ch, vfsPath(args[0]), func(e vfsEntry) error {
+		if !f.Match(e) {
+			return nil
+		}
+		if execArgs != nil {
+			return findExec(env, execArgs, e.Path)
+		}
+		if env.Output == OutputJSON {
+			return env.PrintJSON(newJSONEntry(e))
+		}
+		env.Print(lf.Format(entryVars(e)) + sep)
+
+		return nil
+	})
+}
+
+// walk lists VFS directory recursively calling fn for every entry.
+func walk(ch *chubby.Chubby, dir string, fn func(e vfsEntry) error) error {
+	entries, err := listDir(ch, dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		err := fn(e)
+		if err != nil {
+			return err
+		}
+		if e.Dir {
+			err := walk(ch, e.Path, fn)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// findExec executes command replacing every {} in its arguments with
+// the path. Like in find(1) non-zero exit status of the command is not
+// considered an error.
+func findExec(env *Env, args []string, p string) error {
+	var cargs []string
+	for _, a := range args {
+		cargs = append(cargs, strings.ReplaceAll(a, "{}", p))
+	}
+	cmd := exec.Command(cargs[0], cargs[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = env.Stdout
+	cmd.Stderr = env.Stderr
+	err := cmd.Run()
+	var eerr *exec.ExitError
+	if errors.As(err, &eerr) {
+		return nil
+	}
+
+	return err
+}
+
+type findFilter struct {
+	typ     string
+	artist  func(string) bool
+	album   func(string) bool
+	title   func(string) bool
+	yearMin int
+	yearMax int
+	// Track length limits in seconds, -1 means no limit.
+	lenMin int
+	lenMax int
+	query  *Query
+}
+
+func newFindFilter(opts opt.Options) (*findFilter, error) {
+	var err error
+	f := &findFilter{lenMin: -1, lenMax: -1}
+
+	f.typ = opts.StringOr("type", "")
+	if f.typ != "" && f.typ != "d" && f.typ != "f" {
+		return nil, fmt.Errorf("invalid type: %s", f.typ)
+	}
+	regex := opts.Has("regex")
+	for _, m := range []struct {
+		name string
+		fn   *func(string) bool
+	}{
+		{"artist", &f.artist},
+		{"album", &f.album},
+		{"title", &f.title},
+	} {
+		if opts.Has(m.name) {
+			*m.fn, err = newMatcher(opts.StringOr(m.name, ""), regex)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", m.name, err)
+			}
+		}
+	}
+	if opts.Has("year") {
+		f.yearMin, f.yearMax, err = parseRange(opts.StringOr("year", ""))
+		if err != nil {
+			return nil, fmt.Errorf("year: %w", err)
+		}
+	}
+	if opts.Has("query") {
+		f.query, err = ParseQuery(opts.StringOr("query", ""))
+		if err != nil {
+			return nil, fmt.Errorf("query: %w", err)
+		}
+	}
+	if opts.Has("min-length") {
+		f.lenMin, err = parseSeconds(opts.StringOr("min-length", ""))
+		if err != nil {
+			return nil, fmt.Errorf("min-length: %w", err)
+		}
+	}
+	if opts.Has("max-length") {
+		f.lenMax, err = parseSeconds(opts.StringOr("max-length", ""))
+		if err != nil {
+			return nil, fmt.Errorf("max-length: %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+// trackOnly returns true if filter contains track metadata predicates,
+// which directories never match.
+func (f *findFilter) trackOnly() bool {
+	return f.artist != nil || f.album != nil || f.title != nil ||
+		f.yearMin != 0 || f.yearMax != 0 || f.lenMin >= 0 ||
+		f.lenMax >= 0 || f.query != nil
+}
+
+func (f *findFilter) Match(e vfsEntry) bool {
+	if e.Dir {
+		return f.typ != "f" && !f.trackOnly()
+	}
+	if f.typ == "d" {
+		return false
+	}
+
+	t := e.Track
+	if f.artist != nil && !f.artist(t.Artist) {
+		return false
+	}
+	if f.album != nil && !f.album(t.Album) {
+		return false
+	}
+	if f.title != nil && !f.title(t.Title) {
+		return false
+	}
+	if f.yearMin != 0 && t.Year < f.yearMin {
+		return false
+	}
+	if f.yearMax != 0 && t.Year > f.yearMax {
+		return false
+	}
+	l := seconds(t.Length)
+	if f.lenMin >= 0 && l < f.lenMin {
+		return false
+	}
+	if f.lenMax >= 0 && l > f.lenMax {
+		return false
+	}
+	if f.query != nil && !f.query.Match(newQueryRecord(e)) {
+		return false
+	}
+
+	return true
+}
+
+// newMatcher returns case-insensitive glob or regular expression matcher.
+func newMatcher(pattern string, regex bool) (func(string) bool, error) {
+	if regex {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		return re.MatchString, nil
+	}
+
+	pattern = strings.ToLower(pattern)
+	_, err := path.Match(pattern, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(s string) bool {
+		ok, _ := path.Match(pattern, strings.ToLower(s))
+		return ok
+	}, nil
+}
+
+// parseRange parses FROM-TO, FROM-, -TO or single VALUE range. Zero
+// value is returned for the omitted boundary.
+func parseRange(s string) (int, int, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		to = from
+	}
+	var min, max int
+	var err error
+	if from != "" {
+		min, err = strconv.Atoi(from)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid range: %s", s)
+		}
+	}
+	if to != "" {
+		max, err = strconv.Atoi(to)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid range: %s", s)
+		}
+	}
+	if from == "" && to == "" {
+		return 0, 0, fmt.Errorf("invalid range: %s", s)
+	}
+
+	return min, max, nil
+}
+
+// parseSeconds parses [[hh:]mm:]ss time string into number of seconds.
+func parseSeconds(s string) (int, error) {
+	t, err := time.Parse(s)
+	if err != nil {
+		return 0, errors.New("invalid time format")
+	}
+
+	return seconds(t), nil
+}
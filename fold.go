@@ -0,0 +1,60 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Replacements for letters with diacritics.
+var foldTable = map[rune]string{}
+
+func init() {
+	for _, s := range []string{
+		"aàáâãäåāăą", "cçćĉċč", "dďđ", "eèéêëēĕėęě", "gĝğġģ",
+		"hĥħ", "iìíîïĩīĭįı", "jĵ", "kķ", "lĺļľŀł", "nñńņňŉ",
+		"oòóôõöøōŏő", "rŕŗř", "sśŝşš", "tţťŧ", "uùúûüũūŭůűų",
+		"wŵ", "yýÿŷ", "zźżž", "её",
+	} {
+		rs := []rune(s)
+		for _, r := range rs[1:] {
+			foldTable[r] = string(rs[0])
+		}
+	}
+	foldTable['ß'] = "ss"
+	foldTable['æ'] = "ae"
+	foldTable['œ'] = "oe"
+	foldTable['þ'] = "th"
+}
+
+// fold converts string to lower case and replaces letters with
+// diacritics with their base letters, so strings can be compared
+// in case- and diacritic-insensitive way.
+func fold(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if f, ok := foldTable[r]; ok {
+			b.WriteString(f)
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,341 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format is a compiled list item format string like
+//
+//	%-30{artist}%3n. %.40t%{y? (%y)}\t%l
+//
+// Field specifiers are prefixed with `%` character and can be either
+// a single letter or a long field name in braces. Optional `-` flag
+// aligns field to the left, width pads field with spaces and precision
+// truncates it with ellipsis. %{FIELD?TEXT} prints TEXT only if
+// the field is not empty.
+type Format struct {
+	src   string
+	nodes []formatNode
+}
+
+// FormatError describes format string syntax error.
+type FormatError struct {
+	Format string
+	// Position of the error in runes, starting from 1.
+	Column int
+	Msg    string
+}
+
+// Error returns error message along with the format and a marker
+// pointing at the offending column.
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("column %d: %s\n  %s\n  %s^", e.Column, e.Msg,
+		e.Format, strings.Repeat(" ", e.Column-1))
+}
+
+// Single letter field specifiers.
+var formatLetters = map[rune]string{
+	'/': "/",
+	'A': "album",
+	'a': "artist",
+	'f': "file",
+	'l': "length",
+	'n': "number",
+	'p': "path",
+	'q': "quoted",
+	't': "title",
+	'y': "year",
+}
+
+// Long field names.
+var formatNames = []string{"album", "artist", "file", "length", "listened",
+	"number", "path", "quoted", "time", "title", "year"}
+
+func (f *Format) String() string {
+	return f.src
+}
+
+// Format formats item described by the given variables.
+func (f *Format) Format(vars map[string]string) string {
+	var b strings.Builder
+	writeFormat(&b, f.nodes, vars)
+
+	return b.String()
+}
+
+func writeFormat(b *strings.Builder, nodes []formatNode, vars map[string]string) {
+	for _, n := range nodes {
+		n.write(b, vars)
+	}
+}
+
+// formatValue returns value of the named field.
+func formatValue(name string, vars map[string]string) string {
+	switch name {
+	case "/":
+		if vars["dir"] == "true" {
+			return "/"
+		}
+		return ""
+	case "quoted":
+		return shellQuote(vars["path"] + vars["file"])
+	default:
+		return vars[name]
+	}
+}
+
+type formatNode interface {
+	write(b *strings.Builder, vars map[string]string)
+}
+
+type formatText string
+
+func (n formatText) write(b *strings.Builder, vars map[string]string) {
+	b.WriteString(string(n))
+}
+
+type formatField struct {
+	name  string
+	left  bool
+	width int
+	// Maximum field length, zero means unlimited.
+	max int
+}
+
+func (n *formatField) write(b *strings.Builder, vars map[string]string) {
+	v := formatValue(n.name, vars)
+	l := utf8.RuneCountInString(v)
+	if n.max > 0 && l > n.max {
+		v = string([]rune(v)[:n.max-1]) + "…"
+		l = n.max
+	}
+	pad := ""
+	if l < n.width {
+		pad = strings.Repeat(" ", n.width-l)
+	}
+	if n.left {
+		b.WriteString(v)
+		b.WriteString(pad)
+	} else {
+		b.WriteString(pad)
+		b.WriteString(v)
+	}
+}
+
+type formatCond struct {
+	name  string
+	nodes []formatNode
+}
+
+func (n *formatCond) write(b *strings.Builder, vars map[string]string) {
+	v := formatValue(n.name, vars)
+	// Zero year or number means the tag is missing.
+	if v == "" || v == "0" && (n.name == "year" || n.name == "number") {
+		return
+	}
+	writeFormat(b, n.nodes, vars)
+}
+
+type formatParser struct {
+	src []rune
+	pos int
+}
+
+// ParseFormat compiles format string.
+func ParseFormat(s string) (*Format, error) {
+	p := &formatParser{src: []rune(s)}
+	nodes, err := p.parse(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Format{src: s, nodes: nodes}, nil
+}
+
+// parse parses format string up to the end of input or up to the closing
+// brace of the conditional section if nested is true.
+func (p *formatParser) parse(nested bool) ([]formatNode, error) {
+	var nodes []formatNode
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, formatText(text.String()))
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case r == '\\':
+			s, err := p.parseEscape()
+			if err != nil {
+				return nil, err
+			}
+			text.WriteString(s)
+		case r == '%' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '%':
+			text.WriteRune('%')
+			p.pos += 2
+		case r == '%':
+			flush()
+			n, err := p.parseSpec()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		case r == '}' && nested:
+			flush()
+			p.pos++
+			return nodes, nil
+		default:
+			text.WriteRune(r)
+			p.pos++
+		}
+	}
+	if nested {
+		return nil, p.error(len(p.src), "unterminated conditional section")
+	}
+	flush()
+
+	return nodes, nil
+}
+
+func (p *formatParser) parseEscape() (string, error) {
+	start := p.pos
+	p.pos++
+	if p.pos == len(p.src) {
+		return "", p.error(start, "unterminated escape sequence")
+	}
+	r := p.src[p.pos]
+	p.pos++
+	switch r {
+	case 't':
+		return "\t", nil
+	case 'n':
+		return "\n", nil
+	case '0':
+		return "\x00", nil
+	case '\\', '%', '{', '}':
+		return string(r), nil
+	default:
+		return "", p.error(start,
+			fmt.Sprintf("unknown escape sequence \\%c", r))
+	}
+}
+
+func (p *formatParser) parseSpec() (formatNode, error) {
+	start := p.pos
+	p.pos++
+	f := &formatField{}
+	if p.pos < len(p.src) && p.src[p.pos] == '-' {
+		f.left = true
+		p.pos++
+	}
+	f.width = p.parseNumber()
+	prec := false
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		prec = true
+		p.pos++
+		f.max = p.parseNumber()
+		if f.max == 0 {
+			return nil, p.error(p.pos, "precision expected")
+		}
+	}
+	plain := !f.left && f.width == 0 && !prec
+
+	if p.pos == len(p.src) {
+		return nil, p.error(start, "unterminated format specifier")
+	}
+	r := p.src[p.pos]
+	if r != '{' {
+		name, ok := formatLetters[r]
+		if !ok {
+			return nil, p.error(p.pos,
+				fmt.Sprintf("unknown format specifier %%%c", r))
+		}
+		p.pos++
+		f.name = name
+
+		return f, nil
+	}
+
+	p.pos++
+	nstart := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != '}' && p.src[p.pos] != '?' {
+		p.pos++
+	}
+	if p.pos == len(p.src) {
+		return nil, p.error(start, "unterminated format specifier")
+	}
+	name, ok := formatFieldName(string(p.src[nstart:p.pos]))
+	if !ok {
+		return nil, p.error(nstart, fmt.Sprintf("unknown field %q",
+			string(p.src[nstart:p.pos])))
+	}
+	if p.src[p.pos] == '}' {
+		p.pos++
+		f.name = name
+
+		return f, nil
+	}
+
+	if !plain {
+		return nil, p.error(start,
+			"conditional section can not have width or precision")
+	}
+	p.pos++
+	nodes, err := p.parse(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &formatCond{name: name, nodes: nodes}, nil
+}
+
+func (p *formatParser) parseNumber() int {
+	n := 0
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		n = n*10 + int(p.src[p.pos]-'0')
+		p.pos++
+	}
+
+	return n
+}
+
+func (p *formatParser) error(pos int, msg string) error {
+	return &FormatError{Format: string(p.src), Column: pos + 1, Msg: msg}
+}
+
+// formatFieldName resolves single letter or long field name used
+// inside braces.
+func formatFieldName(s string) (string, bool) {
+	if utf8.RuneCountInString(s) == 1 {
+		name, ok := formatLetters[[]rune(s)[0]]
+		return name, ok
+	}
+	if slices.Contains(formatNames, s) {
+		return s, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	vars := map[string]string{
+		"artist": "ZZ Top",
+		"album":  "XXX",
+		"title":  "Poke Chop Sandwich",
+		"number": "7",
+		"year":   "0",
+		"length": "3:22",
+		"path":   "/ZZ Top/1999 - XXX/",
+		"file":   "07 Poke Chop Sandwich.flac",
+		"dir":    "false",
+	}
+	tests := []struct {
+		format string
+		result string
+	}{
+		{"%a - %t", "ZZ Top - Poke Chop Sandwich"},
+		{"%{artist} / %{A}", "ZZ Top / XXX"},
+		{"[%10a]", "[    ZZ Top]"},
+		{"[%-10a]", "[ZZ Top    ]"},
+		{"[%.8t]", "[Poke Ch…]"},
+		{"[%-10.5t]", "[Poke…     ]"},
+		{"[%3.10a]", "[ZZ Top]"},
+		{"%2n.", " 7."},
+		{"%t%{n? #%n}", "Poke Chop Sandwich #7"},
+		{"%t%{y? (%y)}", "Poke Chop Sandwich"},
+		{"%t%{year? (%{year})}", "Poke Chop Sandwich"},
+		{"%{album?%{n?%A/%n}}", "XXX/7"},
+		{"%a%/", "ZZ Top"},
+		{"%q", "'/ZZ Top/1999 - XXX/07 Poke Chop Sandwich.flac'"},
+		{`100%% \{%a\}`, "100% {ZZ Top}"},
+		{`%a\t%l\n`, "ZZ Top\t3:22\n"},
+		{`a\\b\%c\0`, "a\\b%c\x00"},
+		{"%{listened}", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		f, err := ParseFormat(test.format)
+		if err != nil {
+			t.Errorf("%s: %s", test.format, err)
+			continue
+		}
+		if r := f.Format(vars); r != test.result {
+			t.Errorf("%s: expected %q, got %q", test.format,
+				test.result, r)
+		}
+		if f.String() != test.format {
+			t.Errorf("%s: unexpected source %q", test.format, f)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		format string
+		column int
+		msg    string
+	}{
+		{"%x", 2, "unknown format specifier %x"},
+		{"ab %-5Z", 7, "unknown format specifier %Z"},
+		{"%", 1, "unterminated format specifier"},
+		{"%-10", 1, "unterminated format specifier"},
+		{"%.a", 3, "precision expected"},
+		{"%{artist", 1, "unterminated format specifier"},
+		{"%{foo}", 3, `unknown field "foo"`},
+		{"x%{y? (%y)", 11, "unterminated conditional section"},
+		{"%5{y? %y}", 1, "conditional section can not have width " +
+			"or precision"},
+		{"%{y? %k}", 7, "unknown format specifier %k"},
+		{`a\`, 2, "unterminated escape sequence"},
+		{`a\q`, 2, `unknown escape sequence \q`},
+	}
+
+	for _, test := range tests {
+		_, err := ParseFormat(test.format)
+		var ferr *FormatError
+		if !errors.As(err, &ferr) {
+			t.Errorf("%s: FormatError expected, got %v", test.format,
+				err)
+			continue
+		}
+		if ferr.Column != test.column || ferr.Msg != test.msg {
+			t.Errorf("%s: expected column %d: %s, got column %d: %s",
+				test.format, test.column, test.msg, ferr.Column,
+				ferr.Msg)
+		}
+	}
+}
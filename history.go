@@ -0,0 +1,326 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+// Track is considered listened, not skipped, if it was played for at
+// least a half of its length or for scrobbleMinTime.
+const scrobbleMinTime = 4 * 60
+
+// HistoryRecord describes a single played track.
+type HistoryRecord struct {
+	// Time playback of the track started at.
+	Time   time.Time `json:"time"`
+	Server string    `json:"server"`
+	Path   string    `json:"path"`
+	Artist string    `json:"artist"`
+	Album  string    `json:"album"`
+	Title  string    `json:"title"`
+	Year   int       `json:"year"`
+	Number int       `json:"number"`
+	// Track length in seconds.
+	Length int `json:"length"`
+	// Number of seconds track was actually played for.
+	Listened int  `json:"listened"`
+	Skipped  bool `json:"skipped"`
+}
+
+type HistoryCommand struct {
+}
+
+func NewHistoryCommand() HistoryCommand {
+	return HistoryCommand{}
+}
+
+func (c HistoryCommand) Name() string {
+	return "history"
+}
+
+func (c HistoryCommand) Options() []*opt.Desc {
+	return []*opt.Desc{
+		{"", "album", opt.ArgString, "PATTERN", "match album"},
+		{"", "artist", opt.ArgString, "PATTERN", "match artist"},
+		{"f", "", opt.ArgString, "FORMAT", "record format"},
+		{"", "format", opt.ArgString, "FORMAT",
+			"export format: scrobbler-log, listenbrainz or lastfm-csv"},
+		{"l", "listened", opt.ArgNone, "",
+			"print listened tracks only, omit skipped ones"},
+		{"n", "", opt.ArgInt, "NUMBER",
+			"print only NUMBER most recent records"},
+		{"r", "regex", opt.ArgNone, "",
+			"treat patterns as regular expressions"},
+		{"", "since", opt.ArgString, "DATE",
+			"print tracks played at or after DATE"},
+		{"", "until", opt.ArgString, "DATE",
+			"print tracks played before or at DATE"},
+	}
+}
+
+func (c HistoryCommand) Args() (int, int) {
+	return 0, 1
+}
+
+func (c HistoryCommand) CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error) {
+	if n > 0 {
+		return nil, nil
+	}
+
+	return filterPrefix([]string{"export"}, prefix), nil
+}
+
+func (c HistoryCommand) Local() bool {
+	return true
+}
+
+func (c HistoryCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	export := len(args) > 0
+	if export && args[0] != "export" {
+		return fmt.Errorf("unknown history command: %s", args[0])
+	}
+	if export && !opts.Has("format") {
+		return &usageError{c}
+	}
+	f, err := ParseFormat(opts.StringOr("f", "%{time}  %a - %t"))
+	if err != nil {
+		return fmt.Errorf("format: %w", err)
+	}
+	filter, err := newHistoryFilter(opts)
+	if err != nil {
+		return err
+	}
+	recs, err := loadTrackHistory(filter)
+	if err != nil {
+		return err
+	}
+	if n := opts.IntOr("n", 0); n > 0 && n < len(recs) {
+		recs = recs[len(recs)-n:]
+	}
+	if export {
+		return exportHistory(env.Stdout, opts.StringOr("format", ""), recs)
+	}
+
+	if env.Output == OutputJSON {
+		if recs == nil {
+			recs = []*HistoryRecord{}
+		}
+		return env.PrintJSON(recs)
+	}
+	for _, r := range recs {
+		env.Println(f.Format(r.Vars()))
+	}
+
+	return nil
+}
+
+func newHistoryRecord(s *Settings, t chubby.Track,
+	start time.Time) *HistoryRecord {
+
+	return &HistoryRecord{
+		Time:   start,
+		Server: serverName(s),
+		Path:   t.Path,
+		Artist: t.Artist,
+		Album:  t.Album,
+		Title:  t.Title,
+		Year:   t.Year,
+		Number: t.Number,
+		Length: seconds(t.Length),
+	}
+}
+
+// Vars returns format variables describing the record.
+func (r *HistoryRecord) Vars() map[string]string {
+	p, f := path.Split(r.Path)
+
+	return map[string]string{
+		"dir":      "false",
+		"path":     p,
+		"file":     f,
+		"artist":   r.Artist,
+		"album":    r.Album,
+		"year":     strconv.Itoa(r.Year),
+		"title":    r.Title,
+		"number":   strconv.Itoa(r.Number),
+		"length":   formatSeconds(r.Length),
+		"time":     r.Time.Local().Format("2006-01-02 15:04"),
+		"listened": formatSeconds(r.Listened),
+	}
+}
+
+// trackHistoryPath returns path of the listening history file.
+func trackHistoryPath() string {
+	return filepath.Join(dataHome(), "chubc", "history.jsonl")
+}
+
+// appendTrackHistory appends record to the listening history file.
+func appendTrackHistory(r *HistoryRecord) error {
+	p := trackHistoryPath()
+	err := os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// loadTrackHistory reads history records matching the filter in the order
+// they were recorded. Filter can be nil. Missing history file is not
+// an error.
+func loadTrackHistory(filter *historyFilter) ([]*HistoryRecord, error) {
+	f, err := os.Open(trackHistoryPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var recs []*HistoryRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		r := &HistoryRecord{}
+		// Last line can be truncated if recorder was killed while
+		// writing it, so broken lines are ignored.
+		if json.Unmarshal(sc.Bytes(), r) != nil {
+			continue
+		}
+		if filter == nil || filter.Match(r) {
+			recs = append(recs, r)
+		}
+	}
+
+	return recs, sc.Err()
+}
+
+type historyFilter struct {
+	since    time.Time
+	until    time.Time
+	artist   func(string) bool
+	album    func(string) bool
+	listened bool
+}
+
+func newHistoryFilter(opts opt.Options) (*historyFilter, error) {
+	var err error
+	f := &historyFilter{listened: opts.Has("listened")}
+
+	if opts.Has("since") {
+		f.since, err = parseDate(opts.StringOr("since", ""), false)
+		if err != nil {
+			return nil, fmt.Errorf("since: %w", err)
+		}
+	}
+	if opts.Has("until") {
+		f.until, err = parseDate(opts.StringOr("until", ""), true)
+		if err != nil {
+			return nil, fmt.Errorf("until: %w", err)
+		}
+	}
+	regex := opts.Has("regex")
+	for _, m := range []struct {
+		name string
+		fn   *func(string) bool
+	}{
+		{"artist", &f.artist},
+		{"album", &f.album},
+	} {
+		if opts.Has(m.name) {
+			*m.fn, err = newMatcher(opts.StringOr(m.name, ""), regex)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", m.name, err)
+			}
+		}
+	}
+
+	return f, nil
+}
+
+func (f *historyFilter) Match(r *HistoryRecord) bool {
+	if !f.since.IsZero() && r.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && r.Time.After(f.until) {
+		return false
+	}
+	if f.artist != nil && !f.artist(r.Artist) {
+		return false
+	}
+	if f.album != nil && !f.album(r.Album) {
+		return false
+	}
+	if f.listened && r.Skipped {
+		return false
+	}
+
+	return true
+}
+
+// parseDate parses date in YYYY-MM-DD, YYYY-MM-DD HH:MM or RFC3339
+// format in local time zone. If end is true and time is omitted the end
+// of the day is returned, otherwise the beginning of the day.
+func parseDate(s string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s,
+		time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date format")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	return t, nil
+}
+
+// scrobbleable returns true if track of the given length played for
+// listened seconds counts as listened.
+func scrobbleable(listened int, length int) bool {
+	return listened >= scrobbleMinTime || length > 0 && listened*2 >= length
+}
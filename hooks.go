@@ -0,0 +1,239 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+const (
+	DefaultHookTimeout = 10 * time.Second
+	DefaultHookJobs    = 4
+	// Number of hooks waiting for a free job before new ones are dropped.
+	hookQueueSize = 64
+)
+
+type HooksCommand struct {
+}
+
+func NewHooksCommand() HooksCommand {
+	return HooksCommand{}
+}
+
+func (c HooksCommand) Name() string {
+	return "hooks"
+}
+
+func (c HooksCommand) Options() []*opt.Desc {
+	return []*opt.Desc{
+		{"j", "jobs", opt.ArgInt, "N",
+			"maximum number of hooks running at once"},
+		reconnectOption(),
+		{"", "timeout", opt.ArgString, "DURATION",
+			"kill hooks running longer than DURATION"},
+	}
+}
+
+func (c HooksCommand) Args() (int, int) {
+	return 0, 0
+}
+
+func (c HooksCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+// hookJob is a hook command queued for execution with its input.
+type hookJob struct {
+	hook string
+	in   hookInput
+}
+
+// hookInput is passed to hooks on standard input as JSON object.
+type hookInput struct {
+	*jsonEvent
+	Status *jsonStatus `json:"status,omitempty"`
+}
+
+func (c HooksCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	jobs := opts.IntOr("jobs", DefaultHookJobs)
+	if jobs <= 0 {
+		return errors.New("jobs number must be positive")
+	}
+	timeout := DefaultHookTimeout
+	if opts.Has("timeout") {
+		var err error
+		timeout, err = time.ParseDuration(opts.StringOr("timeout", ""))
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout: %s",
+				opts.StringOr("timeout", ""))
+		}
+	}
+	if s := env.Settings.Config.Section("hooks", ""); s == nil ||
+		len(s.Values) == 0 {
+		return errors.New("no hooks configured")
+	}
+
+	stream, err := openEventStream(env.Settings, ch, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	queue := make(chan hookJob, hookQueueSize)
+	defer close(queue)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			for j := range queue {
+				err := runHook(env, j.hook, j.in, timeout)
+				if err != nil {
+					env.PrintError("hook %s: %s", j.hook, err)
+				}
+			}
+		}()
+	}
+
+	for {
+		select {
+		case e := <-stream.C:
+			if e == nil {
+				return errConnLost
+			}
+			hooks := env.Settings.Config.Hooks(e.Event())
+			if len(hooks) == 0 {
+				continue
+			}
+			in := hookInput{jsonEvent: newJSONEvent(e, time.Now())}
+			// Status is unknown until connection is reestablished.
+			if e.Event() != EventDisconnected {
+				s, ok, err := stream.Status()
+				if err != nil {
+					return err
+				}
+				if ok {
+					in.Status = newJSONStatus(s)
+				}
+			}
+			for _, h := range hooks {
+				select {
+				case queue <- hookJob{h, in}:
+				default:
+					env.PrintError("hook %s: dropped, %d hooks "+
+						"are waiting", h, hookQueueSize)
+				}
+			}
+		case <-sigs:
+			return nil
+		}
+	}
+}
+
+// runHook executes hook command passing event and player status
+// in environment variables and as JSON object on standard input.
+func runHook(env *Env, hook string, in hookInput,
+	timeout time.Duration) error {
+
+	args, err := splitArgs(hook)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("empty command")
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), hookEnv(in)...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = env.Stdout
+	cmd.Stderr = env.Stderr
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("killed after %s", timeout)
+	}
+
+	return err
+}
+
+// hookEnv returns environment variables describing event and player
+// status.
+func hookEnv(in hookInput) []string {
+	env := map[string]string{
+		"CHUB_TIME":       in.Time,
+		"CHUB_EVENT":      in.Event,
+		"CHUB_EVENT_DATA": in.Data,
+	}
+	for k, v := range in.Fields {
+		k = "CHUB_FIELD_" + strings.ToUpper(k)
+		env[k] = fmt.Sprint(v)
+	}
+	if in.Status == nil {
+		return envList(env)
+	}
+	env["CHUB_STATE"] = in.Status.State
+	env["CHUB_VOLUME"] = strconv.Itoa(in.Status.Volume)
+	if p := in.Status.Playlist; p != nil {
+		env["CHUB_PLAYLIST_NAME"] = p.Name
+		env["CHUB_PLAYLIST_LENGTH"] = strconv.Itoa(p.Length)
+		env["CHUB_PLAYLIST_DURATION"] = p.Duration
+		env["CHUB_PLAYLIST_POS"] = strconv.Itoa(in.Status.PlaylistPos)
+	}
+	if t := in.Status.Track; t != nil {
+		env["CHUB_TRACK_PATH"] = t.Path
+		env["CHUB_TRACK_ARTIST"] = t.Artist
+		env["CHUB_TRACK_ALBUM"] = t.Album
+		env["CHUB_TRACK_TITLE"] = t.Title
+		env["CHUB_TRACK_YEAR"] = strconv.Itoa(t.Year)
+		env["CHUB_TRACK_NUMBER"] = strconv.Itoa(t.Number)
+		env["CHUB_TRACK_LENGTH"] = t.Length
+		env["CHUB_TRACK_POS"] = in.Status.TrackPos
+	}
+
+	return envList(env)
+}
+
+func envList(env map[string]string) []string {
+	var res []string
+	for k, v := range env {
+		res = append(res, k+"="+v)
+	}
+
+	return res
+}
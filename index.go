@@ -0,0 +1,329 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+// Index file format version. Increment it on every incompatible change
+// of the Index structure.
+const indexVersion = 1
+
+// Index is a local copy of the server VFS tracks metadata.
+type Index struct {
+	Version int
+	Server  string
+	Updated time.Time
+	Dirs    map[string]*IndexDir
+}
+
+type IndexDir struct {
+	Dirs   []string
+	Tracks []IndexTrack
+}
+
+type IndexTrack struct {
+	Path   string
+	Artist string
+	Album  string
+	Title  string
+	Year   int
+	Number int
+	// Track length in seconds.
+	Length int
+}
+
+type IndexCommand struct {
+}
+
+func NewIndexCommand() IndexCommand {
+	return IndexCommand{}
+}
+
+func (c IndexCommand) Name() string {
+	return "index"
+}
+
+func (c IndexCommand) Options() []*opt.Desc {
+	return []*opt.Desc{
+		{"", "full", opt.ArgNone, "",
+			"re-list all directories, including indexed albums"},
+	}
+}
+
+func (c IndexCommand) Args() (int, int) {
+	return 1, 1
+}
+
+func (c IndexCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+func (c IndexCommand) CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error) {
+	if n > 0 {
+		return nil, nil
+	}
+
+	return filterPrefix([]string{"update"}, prefix), nil
+}
+
+func (c IndexCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	if args[0] != "update" {
+		return fmt.Errorf("unknown index command: %s", args[0])
+	}
+
+	old, err := loadIndex(env.Settings)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	idx := newIndex(env.Settings)
+	u := &indexUpdater{ch: ch, old: old, idx: idx,
+		full: opts.Has("full")}
+	err = u.update("/")
+	if err != nil {
+		return err
+	}
+	err = idx.Save(env.Settings)
+	if err != nil {
+		return err
+	}
+
+	if env.Output == OutputJSON {
+		return env.PrintJSON(map[string]int{
+			"dirs":   len(idx.Dirs),
+			"tracks": u.tracks,
+			"listed": u.listed,
+			"reused": u.reused,
+		})
+	}
+	env.Printf("Indexed %d tracks in %d directories "+
+		"(%d listed, %d reused).\n",
+		u.tracks, len(idx.Dirs), u.listed, u.reused)
+	if u.reused > 0 {
+		env.Printf("Changes inside reused directories are not detected, " +
+			"use --full option to re-list them.\n")
+	}
+
+	return nil
+}
+
+// indexUpdater crawls VFS and builds a new index. Incremental update
+// can not re-list only directories whose contents changed: server
+// reports neither modification times nor sizes of directories, so the
+// only way to learn that a directory changed is to list it. Instead,
+// directories which contain tracks only and are present in the old
+// index are not listed again, unless full update is requested, since
+// in a typical library these are album directories which rarely
+// change. Tracks added to, removed from or retagged in such directories
+// are not noticed, update reports how many directories were reused this
+// way. Directories with subdirectories are always listed to discover
+// added or removed entries.
+type indexUpdater struct {
+	ch     *chubby.Chubby
+	old    *Index
+	idx    *Index
+	full   bool
+	listed int
+	reused int
+	tracks int
+}
+
+func (u *indexUpdater) update(dir string) error {
+	if u.old != nil && !u.full {
+		if od, ok := u.old.Dirs[dir]; ok && len(od.Dirs) == 0 {
+			u.idx.Dirs[dir] = od
+			u.reused++
+			u.tracks += len(od.Tracks)
+			return nil
+		}
+	}
+
+	entries, err := listDir(u.ch, dir)
+	if err != nil {
+		return err
+	}
+	u.listed++
+	d := &IndexDir{}
+	u.idx.Dirs[dir] = d
+	for _, e := range entries {
+		if e.Dir {
+			d.Dirs = append(d.Dirs, e.Path)
+		} else {
+			d.Tracks = append(d.Tracks, IndexTrack{
+				Path:   e.Path,
+				Artist: e.Track.Artist,
+				Album:  e.Track.Album,
+				Title:  e.Track.Title,
+				Year:   e.Track.Year,
+				Number: e.Track.Number,
+				Length: seconds(e.Track.Length),
+			})
+		}
+	}
+	u.tracks += len(d.Tracks)
+	for _, sd := range d.Dirs {
+		err := u.update(sd)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newIndex(s *Settings) *Index {
+	return &Index{
+		Version: indexVersion,
+		Server:  serverName(s),
+		Updated: time.Now(),
+		Dirs:    map[string]*IndexDir{},
+	}
+}
+
+// serverName returns host:port string of the server.
+func serverName(s *Settings) string {
+	return s.ServerAddr().String()
+}
+
+// serverFileName returns server name suitable for use in file names.
+func serverFileName(s *Settings) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+			r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, serverName(s))
+}
+
+// indexPath returns path of the index file for the server.
+func indexPath(s *Settings) string {
+	return filepath.Join(cacheHome(), "chubc", "index-"+serverFileName(s))
+}
+
+// loadIndex reads index of the server. os.ErrNotExist is returned if
+// index does not exist or is outdated.
+func loadIndex(s *Settings) (*Index, error) {
+	f, err := os.Open(indexPath(s))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	idx := &Index{}
+	err = gob.NewDecoder(f).Decode(idx)
+	if err != nil || idx.Version != indexVersion {
+		return nil, os.ErrNotExist
+	}
+
+	return idx, nil
+}
+
+// Save writes index of the server to the cache directory atomically.
+func (idx *Index) Save(s *Settings) error {
+	p := indexPath(s)
+	err := os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), ".index-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	err = gob.NewEncoder(f).Encode(idx)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), p)
+}
+
+// Tracks calls fn for every indexed track.
+func (idx *Index) Tracks(fn func(t *IndexTrack)) {
+	for _, d := range idx.Dirs {
+		for i := range d.Tracks {
+			fn(&d.Tracks[i])
+		}
+	}
+}
+
+// countTracks returns number of tracks in the directory and all its
+// subdirectories.
+func (idx *Index) countTracks(dir string) int {
+	d, ok := idx.Dirs[dir]
+	if !ok {
+		return 0
+	}
+	n := len(d.Tracks)
+	for _, sd := range d.Dirs {
+		n += idx.countTracks(sd)
+	}
+
+	return n
+}
+
+// Vars returns format variables describing the track.
+func (t *IndexTrack) Vars() map[string]string {
+	p, f := path.Split(t.Path)
+
+	return map[string]string{
+		"dir":    "false",
+		"path":   p,
+		"file":   f,
+		"artist": t.Artist,
+		"album":  t.Album,
+		"year":   strconv.Itoa(t.Year),
+		"title":  t.Title,
+		"number": strconv.Itoa(t.Number),
+		"length": formatSeconds(t.Length),
+	}
+}
+
+func (t *IndexTrack) JSON() *jsonEntry {
+	return &jsonEntry{
+		Dir:  false,
+		Path: t.Path,
+		Track: &jsonTrack{
+			Path:   t.Path,
+			Artist: t.Artist,
+			Album:  t.Album,
+			Title:  t.Title,
+			Year:   t.Year,
+			Number: t.Number,
+			Length: formatSeconds(t.Length),
+		},
+	}
+}
@@ -41,6 +41,6 @@ func (c KillCommand) Args() (int, int) {
 	return 0, 0
 }
 
-func (c KillCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.Kill()
+func (c KillCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	return rejected(ch.Kill())
 }
@@ -0,0 +1,263 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Maximum number of history lines kept by line editor.
+const historySize = 1000
+
+var errInterrupted = errors.New("interrupted")
+
+// LineEditor reads lines from the terminal providing basic emacs-like
+// line editing and history navigation. If standard input is not
+// a terminal lines are read as is, without any editing.
+type LineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	fd      int
+	tty     bool
+	history []string
+}
+
+func NewLineEditor(history []string) *LineEditor {
+	fd := int(os.Stdin.Fd())
+
+	return &LineEditor{
+		in:      bufio.NewReader(os.Stdin),
+		out:     os.Stdout,
+		fd:      fd,
+		tty:     isTerminal(fd),
+		history: history,
+	}
+}
+
+func (e *LineEditor) AddHistory(line string) {
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > historySize {
+		e.history = e.history[len(e.history)-historySize:]
+	}
+}
+
+// ReadLine prints prompt and reads a single line. io.EOF is returned
+// if input is closed or Ctrl-D is pressed on empty line, errInterrupted
+// is returned if Ctrl-C is pressed.
+func (e *LineEditor) ReadLine(prompt string) (string, error) {
+	if !e.tty {
+		line, err := e.in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	st, err := makeRaw(e.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restoreTerm(e.fd, st)
+
+	return e.edit(prompt)
+}
+
+func (e *LineEditor) edit(prompt string) (string, error) {
+	var line []rune
+	var pos int = 0
+	// Position in history, len(history) is the line being edited.
+	var hpos int = len(e.history)
+	var saved []rune
+
+	setLine := func(s []rune) {
+		line = append([]rune{}, s...)
+		pos = len(line)
+	}
+	e.refresh(prompt, line, pos)
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(line), nil
+		case 1: // Ctrl-A
+			pos = 0
+		case 2: // Ctrl-B
+			if pos > 0 {
+				pos--
+			}
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(line) {
+				line = append(line[:pos], line[pos+1:]...)
+			}
+		case 5: // Ctrl-E
+			pos = len(line)
+		case 6: // Ctrl-F
+			if pos < len(line) {
+				pos++
+			}
+		case 8, 127: // Ctrl-H, Backspace
+			if pos > 0 {
+				line = append(line[:pos-1], line[pos:]...)
+				pos--
+			}
+		case 11: // Ctrl-K
+			line = line[:pos]
+		case 12: // Ctrl-L
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case 14, 16: // Ctrl-N, Ctrl-P
+			hpos, saved = e.navigate(r == 16, hpos, line, saved,
+				setLine)
+		case 21: // Ctrl-U
+			line = line[pos:]
+			pos = 0
+		case 23: // Ctrl-W
+			i := pos
+			for i > 0 && line[i-1] == ' ' {
+				i--
+			}
+			for i > 0 && line[i-1] != ' ' {
+				i--
+			}
+			line = append(line[:i], line[pos:]...)
+			pos = i
+		case 27: // Escape sequence
+			switch readEscape(e.in) {
+			case 'A':
+				hpos, saved = e.navigate(true, hpos, line,
+					saved, setLine)
+			case 'B':
+				hpos, saved = e.navigate(false, hpos, line,
+					saved, setLine)
+			case 'C':
+				if pos < len(line) {
+					pos++
+				}
+			case 'D':
+				if pos > 0 {
+					pos--
+				}
+			case 'H':
+				pos = 0
+			case 'F':
+				pos = len(line)
+			case '3':
+				if pos < len(line) {
+					line = append(line[:pos], line[pos+1:]...)
+				}
+			}
+		default:
+			if r >= ' ' {
+				line = append(line[:pos],
+					append([]rune{r}, line[pos:]...)...)
+				pos++
+			}
+		}
+		e.refresh(prompt, line, pos)
+	}
+}
+
+// readEscape reads the rest of the escape sequence and returns its final
+// character. Home, End, Delete, Page Up and Page Down keys are mapped
+// to 'H', 'F', '3', '5' and '6'.
+func readEscape(in *bufio.Reader) rune {
+	r, _, err := in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+	r, _, err = in.ReadRune()
+	if err != nil {
+		return 0
+	}
+	if r < '0' || r > '9' {
+		return r
+	}
+
+	// Sequences with numeric parameters like ESC [ 3 ~ or ESC [ 1 ; 5 C.
+	n := r
+	for {
+		r, _, err = in.ReadRune()
+		if err != nil {
+			return 0
+		}
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+	}
+	if r != '~' {
+		return r
+	}
+	switch n {
+	case '1', '7':
+		return 'H'
+	case '4', '8':
+		return 'F'
+	}
+
+	return n
+}
+
+func (e *LineEditor) navigate(back bool, hpos int, line []rune,
+	saved []rune, setLine func([]rune)) (int, []rune) {
+
+	if hpos == len(e.history) {
+		saved = append([]rune{}, line...)
+	}
+	if back && hpos > 0 {
+		hpos--
+	} else if !back && hpos < len(e.history) {
+		hpos++
+	} else {
+		return hpos, saved
+	}
+	if hpos == len(e.history) {
+		setLine(saved)
+	} else {
+		setLine([]rune(e.history[hpos]))
+	}
+
+	return hpos, saved
+}
+
+func (e *LineEditor) refresh(prompt string, line []rune, pos int) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(line))
+	if n := len(line) - pos; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
@@ -19,9 +19,9 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"path"
 	"strconv"
-	"strings"
 
 	"github.com/vchimishuk/chubby"
 	"github.com/vchimishuk/opt"
@@ -40,89 +40,99 @@ func (c ListCommand) Name() string {
 
 func (c ListCommand) Options() []*opt.Desc {
 	return []*opt.Desc{
+		{"", "exact", opt.ArgNone, "",
+			"list exactly given path, do not match it fuzzily"},
 		{"f", "", opt.ArgString, "FORMAT", "list item format"},
+		templateOption(),
 	}
 }
 
 func (c ListCommand) Args() (int, int) {
-	return 1, 1
+	return 1, math.MaxInt
 }
 
-func (c ListCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	f := opts.StringOr("f", "%f%/")
+func (c ListCommand) CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error) {
+	if n > 0 {
+		return nil, nil
+	}
+
+	return completeVFS(ch, prefix)
+}
 
-	entries, err := ch.List(args[0])
+func (c ListCommand) Interactive(opts opt.Options) bool {
+	return !opts.Has("exact")
+}
+
+func (c ListCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	f, err := ParseFormat(opts.StringOr("f", env.Settings.Format.Value))
+	if err != nil {
+		return fmt.Errorf("format: %w", err)
+	}
+	t, err := parseTemplate(opts)
 	if err != nil {
 		return err
 	}
-	for _, e := range entries {
-		vars := map[string]string{}
-
-		if e.IsDir() {
-			p, f := path.Split(e.Dir().Path)
-			vars["dir"] = "true"
-			vars["path"] = p
-			vars["file"] = f
-		} else {
-			p, f := path.Split(e.Track().Path)
-			vars["dir"] = "false"
-			vars["path"] = p
-			vars["file"] = f
-			vars["artist"] = e.Track().Artist
-			vars["album"] = e.Track().Album
-			vars["year"] = strconv.Itoa(e.Track().Year)
-			vars["title"] = e.Track().Title
-			vars["number"] = strconv.Itoa(e.Track().Number)
-			vars["length"] = e.Track().Length.String()
+
+	var p string
+	if opts.Has("exact") {
+		if len(args) > 1 {
+			return &usageError{c}
+		}
+		p = vfsPath(args[0])
+	} else {
+		p, err = resolvePath(env, ch, args)
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err := listDir(ch, p)
+	if err != nil {
+		return err
+	}
+	if t != nil {
+		for _, e := range entries {
+			err := printTemplate(env.Stdout, t, e)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+	if env.Output == OutputJSON {
+		jents := []*jsonEntry{}
+		for _, e := range entries {
+			jents = append(jents, newJSONEntry(e))
 		}
 
-		fmt.Print(format(f, vars))
-		fmt.Println()
+		return env.PrintJSON(jents)
+	}
+	for _, e := range entries {
+		env.Println(f.Format(entryVars(e)))
 	}
 
 	return nil
 }
 
-func format(fmt string, vars map[string]string) string {
-	var spec bool = false
-	var s strings.Builder
-
-	for _, r := range fmt {
-		if !spec && r == '%' {
-			spec = true
-		} else if spec {
-			switch r {
-			case '%':
-				s.WriteRune(r)
-			case '/':
-				if vars["dir"] == "true" {
-					s.WriteRune('/')
-				}
-			case 'A':
-				s.WriteString(vars["album"])
-			case 'a':
-				s.WriteString(vars["artist"])
-			case 'f':
-				s.WriteString(vars["file"])
-			case 'l':
-				s.WriteString(vars["length"])
-			case 'n':
-				s.WriteString(vars["number"])
-			case 'p':
-				s.WriteString(vars["path"])
-			case 't':
-				s.WriteString(vars["title"])
-			case 'y':
-				s.WriteString(vars["year"])
-			default:
-				s.WriteRune('%')
-				s.WriteRune(r)
-			}
-			spec = false
-		} else {
-			s.WriteRune(r)
-		}
+// entryVars returns format variables describing the entry.
+func entryVars(e vfsEntry) map[string]string {
+	vars := map[string]string{}
+	p, f := path.Split(e.Path)
+	vars["path"] = p
+	vars["file"] = f
+
+	if e.Dir {
+		vars["dir"] = "true"
+	} else {
+		vars["dir"] = "false"
+		vars["artist"] = e.Track.Artist
+		vars["album"] = e.Track.Album
+		vars["year"] = strconv.Itoa(e.Track.Year)
+		vars["title"] = e.Track.Title
+		vars["number"] = strconv.Itoa(e.Track.Number)
+		vars["length"] = e.Track.Length.String()
 	}
 
-	return s.String()
+	return vars
 }
@@ -18,19 +18,46 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"slices"
-	"strconv"
 
 	"github.com/vchimishuk/chubby"
 	"github.com/vchimishuk/opt"
 )
 
+// Process exit statuses.
+const (
+	ExitFailure = 1
+	ExitUsage   = 2
+	// Connection to the server is lost by a long-running command.
+	ExitConnLost = 3
+	ExitConnect  = 4
+	ExitTimeout  = 5
+	// Server rejected the command.
+	ExitRejected = 6
+	ExitNotFound = 7
+	// Commands failed on some of several servers.
+	ExitPartial = 8
+)
+
+// Continue execution of the rest of commands if one fails.
+var keepGoing bool = false
+
 var Commands []Command = []Command{
+	NewBarCommand(),
+	NewBatchCommand(),
+	NewCompletionCommand(),
+	NewConfigCommand(),
 	NewCreatePlaylistCommand(),
+	NewDaemonCommand(),
 	NewDeletePlaylistCommand(),
 	NewEventsCommand(),
+	NewFindCommand(),
+	NewHistoryCommand(),
+	NewHooksCommand(),
+	NewIndexCommand(),
 	NewKillCommand(),
 	NewListCommand(),
 	NewNextCommand(),
@@ -39,10 +66,16 @@ var Commands []Command = []Command{
 	NewPlayCommand(),
 	NewPlaylistsCommand(),
 	NewPrevCommand(),
+	NewQueryCommand(),
 	NewRenamePlaylistCommand(),
+	NewScrobbleDaemonCommand(),
+	NewSearchCommand(),
 	NewSeekCommand(),
+	NewShellCommand(),
+	NewStatsCommand(),
 	NewStatusCommand(),
 	NewStopCommand(),
+	NewTuiCommand(),
 	NewVolumeCommand(),
 }
 
@@ -57,32 +90,129 @@ func command(name string) Command {
 	return Commands[i]
 }
 
+type usageError struct {
+	cmd Command
+}
+
+func (e *usageError) Error() string {
+	return fmt.Sprintf("invalid %s command usage", e.cmd.Name())
+}
+
+// execCommand parses command arguments and executes the command.
+// First argument is a command name.
+func execCommand(env *Env, c *chubby.Chubby, args []string) error {
+	cmd := command(args[0])
+	if cmd == nil {
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+
+	args = args[1:]
+	if rw, ok := cmd.(ArgsRewriter); ok {
+		var err error
+		args, err = rw.RewriteArgs(args)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts, args, err := opt.Parse(args, cmd.Options(), false)
+	mina, maxa := cmd.Args()
+	if err != nil || len(args) < mina || len(args) > maxa {
+		return &usageError{cmd}
+	}
+
+	return execDeadline(env, c, cmd, opts, args)
+}
+
+// runCommand executes command with the given arguments and without
+// any options.
+func runCommand(env *Env, c *chubby.Chubby, cmd Command,
+	args ...string) error {
+
+	opts, _, err := opt.Parse(nil, cmd.Options(), false)
+	if err != nil {
+		return err
+	}
+
+	return cmd.Exec(env, c, opts, args)
+}
+
 func prog() string {
 	return os.Args[0]
 }
 
-func fatal(format string, args ...interface{}) {
+// splitChain splits command line arguments into separate commands
+// delimited with semicolon arguments. Semicolon which terminates find
+// command -exec option is not a delimiter.
+func splitChain(args []string) [][]string {
+	var chain [][]string
+	start := 0
+	exec := false
+	for i := 0; i <= len(args); i++ {
+		if i < len(args) && args[i] == "-exec" {
+			exec = true
+		} else if exec && i < len(args) && args[i] == ";" {
+			exec = false
+		} else if i == len(args) || args[i] == ";" {
+			if i > start {
+				chain = append(chain, args[start:i])
+			}
+			start = i + 1
+		}
+	}
+
+	return chain
+}
+
+func fatal(status int, format string, args ...interface{}) {
+	printError(format, args...)
+	os.Exit(status)
+}
+
+func printError(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintf(os.Stderr, "%s: %s\n", prog(), msg)
-	os.Exit(1)
+	if output == OutputJSON {
+		writeJSON(os.Stderr, jsonError{msg})
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", prog(), msg)
+	}
 }
 
 func printUsage(opts []*opt.Desc) {
-	fmt.Printf("Usage: %s [OPTIONS] COMMAND [ARG]...\n", prog())
+	fmt.Printf("Usage: %s [OPTIONS] COMMAND [ARG]... [\\; COMMAND [ARG]...]...\n",
+		prog())
 	fmt.Printf("Simple Chub non-interactive client.\n")
 	fmt.Printf("\n")
 	fmt.Printf("Options:\n")
 	fmt.Printf("%s", opt.Usage(opts))
 	fmt.Printf("\n")
 	fmt.Printf("Commands:\n")
+	fmt.Printf("  bar              ")
+	fmt.Printf("Print player status for status bars.\n")
+	fmt.Printf("  batch            ")
+	fmt.Printf("Execute commands from file or stdin.\n")
+	fmt.Printf("  completion       ")
+	fmt.Printf("Print shell completion script.\n")
+	fmt.Printf("  config           ")
+	fmt.Printf("Validate config file and show effective settings.\n")
 	fmt.Printf("  create-playlist  ")
 	fmt.Printf("Create new playlist.\n")
+	fmt.Printf("  daemon           ")
+	fmt.Printf("Keep server connection for other invocations.\n")
 	fmt.Printf("  delete-playlist  ")
 	fmt.Printf("Delete existing playlist.\n")
 	fmt.Printf("  events           ")
 	fmt.Printf("Listen for events and print them to stdout.\n")
+	fmt.Printf("  find             ")
+	fmt.Printf("Search VFS directory recursively.\n")
 	fmt.Printf("  help             ")
 	fmt.Printf("Show this help.\n")
+	fmt.Printf("  history          ")
+	fmt.Printf("Print listening history.\n")
+	fmt.Printf("  hooks            ")
+	fmt.Printf("Run configured hooks on player events.\n")
+	fmt.Printf("  index            ")
+	fmt.Printf("Update local library index.\n")
 	fmt.Printf("  kill             ")
 	fmt.Printf("Kill Chub server.\n")
 	fmt.Printf("  list             ")
@@ -99,19 +229,31 @@ func printUsage(opts []*opt.Desc) {
 	fmt.Printf("Print list of existing playlists.\n")
 	fmt.Printf("  prev             ")
 	fmt.Printf("Move playback to the previous track in the playlist.\n")
+	fmt.Printf("  query            ")
+	fmt.Printf("List, run or play named queries.\n")
 	fmt.Printf("  rename-playlist  ")
 	fmt.Printf("Rename playlist.\n")
+	fmt.Printf("  scrobble-daemon  ")
+	fmt.Printf("Record listening history.\n")
+	fmt.Printf("  search           ")
+	fmt.Printf("Search local library index.\n")
 	fmt.Printf("  seek             ")
 	fmt.Printf("Seek playback time.\n")
+	fmt.Printf("  shell            ")
+	fmt.Printf("Start interactive shell.\n")
+	fmt.Printf("  stats            ")
+	fmt.Printf("Print listening statistics.\n")
 	fmt.Printf("  status           ")
 	fmt.Printf("Print Chub player current status.\n")
 	fmt.Printf("  stop             ")
 	fmt.Printf("Stop playback.\n")
+	fmt.Printf("  tui              ")
+	fmt.Printf("Start full-screen terminal interface.\n")
 	fmt.Printf("  volume           ")
 	fmt.Printf("Set playback volume.\n")
 }
 
-func printCommandUsage(cmd Command) {
+func printCommandUsage(env *Env, cmd Command) {
 	opts := ""
 	if len(cmd.Options()) != 0 {
 		opts = " [OPTIONS]"
@@ -121,26 +263,65 @@ func printCommandUsage(cmd Command) {
 	if n > 0 {
 		args = " [ARG]..."
 	}
-	fmt.Printf("Usage: %s %s%s%s\n", prog(), cmd.Name(), opts, args)
+	env.Printf("Usage: %s %s%s%s\n", prog(), cmd.Name(), opts, args)
 	if opts != "" {
-		fmt.Printf("\n")
-		fmt.Printf("Options:\n")
-		fmt.Printf("%s", opt.Usage(cmd.Options()))
+		env.Printf("\n")
+		env.Printf("Options:\n")
+		env.Printf("%s", opt.Usage(cmd.Options()))
 	}
 }
 
-func main() {
-	optDescs := []*opt.Desc{
+func globalOptions() []*opt.Desc {
+	return []*opt.Desc{
+		{"", "all", opt.ArgNone, "",
+			"execute commands on all configured servers"},
 		{"h", "host", opt.ArgString, "HOST",
 			"server host name"},
 		{"", "help", opt.ArgNone, "",
 			"display this help"},
+		{"k", "keep-going", opt.ArgNone, "",
+			"continue after failed command"},
+		{"", "no-daemon", opt.ArgNone, "",
+			"connect to the server directly even if daemon is running"},
+		{"o", "output", opt.ArgString, "MODE",
+			"output mode: text or json"},
 		{"p", "port", opt.ArgInt, "PORT",
-			"server port"}}
+			"server port"},
+		{"", "profile", opt.ArgString, "NAME",
+			"config file profile to use"},
+		{"", "proxy-command", opt.ArgString, "COMMAND",
+			"connect through standard input and output of COMMAND"},
+		{"", "retries", opt.ArgInt, "NUMBER",
+			"number of connection retries"},
+		{"", "servers", opt.ArgString, "NAME[,NAME]",
+			"execute commands on servers of the given profiles"},
+		{"", "socks5", opt.ArgString, "HOST:PORT",
+			"connect through SOCKS5 proxy"},
+		{"", "timeout", opt.ArgString, "DURATION",
+			"connection and command timeout"},
+		{"", "via-daemon", opt.ArgNone, "",
+			"execute commands through the running daemon"}}
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "__complete" {
+		for _, c := range complete(os.Args[2:]) {
+			fmt.Println(c)
+		}
+		os.Exit(0)
+	}
 
+	optDescs := globalOptions()
 	opts, args, err := opt.Parse(os.Args[1:], optDescs, true)
 	if err != nil {
-		fatal("invalid parameters: %s", err)
+		fatal(ExitUsage, "invalid parameters: %s", err)
+	}
+
+	output = opts.StringOr("output", OutputText)
+	if output != OutputText && output != OutputJSON {
+		output = OutputText
+		fatal(ExitUsage, "invalid output mode: %s",
+			opts.StringOr("output", ""))
 	}
 
 	help := opts.Has("help")
@@ -148,50 +329,130 @@ func main() {
 		printUsage(optDescs)
 		os.Exit(0)
 	}
-	if len(args) == 0 || args[0] == "help" {
+	keepGoing = opts.Has("keep-going")
+	if len(args) == 0 || args[0] == "help" || args[0] == ";" {
 		printUsage(optDescs)
 		os.Exit(0)
 	}
 
-	defaultHost, ok := os.LookupEnv("CHUBC_HOST")
-	if !ok {
-		defaultHost = "localhost"
-	}
-	host := opts.StringOr("host", defaultHost)
-	defaultPortStr, ok := os.LookupEnv("CHUBC_PORT")
-	if !ok {
-		defaultPortStr = "5115"
-	}
-	defaultPort, err := strconv.Atoi(defaultPortStr)
+	settings, err = resolveSettings(opts)
 	if err != nil {
-		fatal("invalid port number: %s", defaultPortStr)
+		fatal(ExitFailure, "%s", err)
 	}
-	port := opts.IntOr("port", defaultPort)
-	c := &chubby.Chubby{}
+	output = settings.Output.Value
 
-	err = c.Connect(host, port)
-	if err != nil {
-		fatal("unnable to connect to remote host: %s", err)
+	chain := splitChain(args)
+	local := true
+	for _, a := range chain {
+		cmd := command(a[0])
+		if cmd == nil && len(chain) > 1 {
+			fatal(ExitUsage, "unknown command: %s", a[0])
+		} else if cmd == nil {
+			printUsage(optDescs)
+			os.Exit(ExitUsage)
+		}
+		local = local && isLocal(cmd)
 	}
-	defer c.Close()
 
-	cmd := command(args[0])
-	if cmd == nil {
-		printUsage(optDescs)
-		os.Exit(0)
+	if opts.Has("all") || opts.Has("servers") {
+		servers, err := fanOutServers(opts)
+		if err != nil {
+			fatal(ExitUsage, "%s", err)
+		}
+		if !forwardable(chain) {
+			fatal(ExitUsage, "only commands which need server "+
+				"connection and complete quickly can be executed "+
+				"on several servers")
+		}
+		os.Exit(fanOut(opts, servers, chain))
 	}
 
-	opts, args, err = opt.Parse(args[1:], cmd.Options(), false)
-	mina, maxa := cmd.Args()
-	if err != nil || len(args) < mina || len(args) > maxa {
-		printCommandUsage(cmd)
-		os.Exit(1)
+	viaDaemon := opts.Has("via-daemon")
+	if viaDaemon && opts.Has("no-daemon") {
+		fatal(ExitUsage, "--via-daemon and --no-daemon can not be "+
+			"used together")
+	}
+	if !local && !opts.Has("no-daemon") && forwardable(chain) &&
+		(viaDaemon || !interactive(chain)) {
+		conn, err := dialDaemon()
+		if err == nil {
+			status, err := forwardDaemon(conn, args)
+			if err != nil {
+				fatal(ExitConnect, "daemon: %s", err)
+			}
+			os.Exit(status)
+		} else if viaDaemon {
+			fatal(ExitConnect, "daemon is not running: %s", err)
+		}
 	}
 
-	err = cmd.Exec(c, opts, args)
-	if err != nil {
-		fatal("%s", err)
+	var c *chubby.Chubby
+	if !local {
+		c, err = connect()
+		if err != nil {
+			fatal(exitStatus(err),
+				"unable to connect to remote host: %s", err)
+		}
+		defer c.Close()
+	}
+
+	os.Exit(runChain(processEnv(), c, chain))
+}
+
+// runChain executes chained commands, prints their errors and returns
+// process exit status. Connection closed after a timeout is redialed
+// for the rest of commands and closed before return.
+func runChain(env *Env, c *chubby.Chubby, chain [][]string) int {
+	orig := c
+	defer func() {
+		if c != orig {
+			c.Close()
+		}
+	}()
+
+	status := 0
+	for i, a := range chain {
+		err := execCommand(env, c, a)
+		// Usage of commands executed by batch is printed by batch.
+		if uerr, ok := err.(*usageError); ok {
+			printCommandUsage(env, uerr.cmd)
+		} else if err != nil && len(chain) > 1 {
+			env.PrintError("command %d (%s): %s", i+1, a[0], err)
+		} else if err != nil {
+			env.PrintError("%s", err)
+		}
+		if err != nil {
+			status = exitStatus(err)
+			if !env.KeepGoing {
+				break
+			}
+			c = redial(env, c, err)
+		}
 	}
 
-	os.Exit(0)
+	return status
+}
+
+// exitStatus returns process exit status for the command error.
+func exitStatus(err error) int {
+	var uerr *usageError
+	var nerr *notFoundError
+	var serr *serverError
+	var cerr *connectError
+	switch {
+	case errors.As(err, &uerr):
+		return ExitUsage
+	case isTimeout(err):
+		return ExitTimeout
+	case errors.Is(err, errConnLost):
+		return ExitConnLost
+	case errors.As(err, &cerr), isConnError(err):
+		return ExitConnect
+	case errors.As(err, &nerr):
+		return ExitNotFound
+	case errors.As(err, &serr):
+		return ExitRejected
+	default:
+		return ExitFailure
+	}
 }
@@ -41,6 +41,6 @@ func (c NextCommand) Args() (int, int) {
 	return 0, 0
 }
 
-func (c NextCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.Next()
+func (c NextCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	return rejected(ch.Next())
 }
@@ -0,0 +1,134 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+)
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Output mode selected with -o command line option.
+var output string = OutputText
+
+type jsonTrack struct {
+	Path   string `json:"path"`
+	Artist string `json:"artist"`
+	Album  string `json:"album"`
+	Title  string `json:"title"`
+	Year   int    `json:"year"`
+	Number int    `json:"number"`
+	Length string `json:"length"`
+}
+
+type jsonPlaylist struct {
+	Name     string `json:"name"`
+	Length   int    `json:"length"`
+	Duration string `json:"duration"`
+}
+
+type jsonStatus struct {
+	State       string        `json:"state"`
+	Volume      int           `json:"volume"`
+	Playlist    *jsonPlaylist `json:"playlist,omitempty"`
+	PlaylistPos int           `json:"playlist_pos,omitempty"`
+	Track       *jsonTrack    `json:"track,omitempty"`
+	TrackPos    string        `json:"track_pos,omitempty"`
+}
+
+type jsonEntry struct {
+	Dir   bool       `json:"dir"`
+	Path  string     `json:"path"`
+	Track *jsonTrack `json:"track,omitempty"`
+}
+
+type jsonEvent struct {
+	Time   string                 `json:"time"`
+	Event  string                 `json:"event"`
+	Data   string                 `json:"data"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+func newJSONTrack(t chubby.Track) *jsonTrack {
+	return &jsonTrack{
+		Path:   t.Path,
+		Artist: t.Artist,
+		Album:  t.Album,
+		Title:  t.Title,
+		Year:   t.Year,
+		Number: t.Number,
+		Length: t.Length.String(),
+	}
+}
+
+func newJSONPlaylist(p chubby.Playlist) *jsonPlaylist {
+	return &jsonPlaylist{
+		Name:     p.Name,
+		Length:   p.Length,
+		Duration: p.Duration.String(),
+	}
+}
+
+func newJSONEntry(e vfsEntry) *jsonEntry {
+	je := &jsonEntry{Dir: e.Dir, Path: e.Path}
+	if !e.Dir {
+		je.Track = newJSONTrack(e.Track)
+	}
+
+	return je
+}
+
+func newJSONEvent(e Event, t time.Time) *jsonEvent {
+	return &jsonEvent{
+		Time:   t.Format(time.RFC3339),
+		Event:  e.Event(),
+		Data:   e.Serialize(),
+		Fields: decodeEventData(e.Serialize()),
+	}
+}
+
+func newJSONStatus(s chubby.Status) *jsonStatus {
+	js := &jsonStatus{
+		State:  fmt.Sprintf("%s", s.State),
+		Volume: s.Volume,
+	}
+	if s.State != chubby.StateStopped {
+		js.Playlist = newJSONPlaylist(s.Playlist)
+		js.PlaylistPos = s.PlaylistPos + 1
+		js.Track = newJSONTrack(s.Track)
+		js.TrackPos = s.TrackPos.String()
+	}
+
+	return js
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
@@ -41,6 +41,6 @@ func (c PauseCommand) Args() (int, int) {
 	return 0, 0
 }
 
-func (c PauseCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.Pause()
+func (c PauseCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	return rejected(ch.Pause())
 }
@@ -0,0 +1,104 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// Connections made by the current process can be recognized, so they
+// are relayed.
+const relaySupported = true
+
+// Maximum number of file descriptors looked through by ownConn if open
+// descriptors can not be listed.
+const maxPeerFds = 1 << 16
+
+// ownConn returns true if accepted TCP connection is made by the current
+// process. Process file descriptors are looked through for the socket
+// bound to the connection peer address and connected to its local one.
+func ownConn(c net.Conn) bool {
+	local, ok := c.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	peer, ok := c.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	for _, fd := range processFds() {
+		sa, err := syscall.Getsockname(fd)
+		if err != nil || !sockaddrEqual(sa, peer) {
+			continue
+		}
+		pa, err := syscall.Getpeername(fd)
+		if err == nil && sockaddrEqual(pa, local) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// processFds returns file descriptors open by the current process. They
+// are listed in /proc/self/fd if procfs is available, otherwise all
+// descriptors below the open files limit are returned.
+func processFds() []int {
+	var fds []int
+	ents, err := os.ReadDir("/proc/self/fd")
+	if err == nil {
+		for _, e := range ents {
+			if fd, err := strconv.Atoi(e.Name()); err == nil {
+				fds = append(fds, fd)
+			}
+		}
+		return fds
+	}
+
+	n := maxPeerFds
+	var lim syscall.Rlimit
+	if syscall.Getrlimit(syscall.RLIMIT_NOFILE, &lim) == nil {
+		// Limit is signed on some systems and infinity is negative
+		// after conversion.
+		if cur := int64(lim.Cur); cur > 0 && cur < maxPeerFds {
+			n = int(cur)
+		}
+	}
+	for fd := 0; fd < n; fd++ {
+		fds = append(fds, fd)
+	}
+
+	return fds
+}
+
+func sockaddrEqual(sa syscall.Sockaddr, a *net.TCPAddr) bool {
+	switch sa := sa.(type) {
+	case *syscall.SockaddrInet4:
+		return sa.Port == a.Port && net.IP(sa.Addr[:]).Equal(a.IP)
+	case *syscall.SockaddrInet6:
+		return sa.Port == a.Port && net.IP(sa.Addr[:]).Equal(a.IP)
+	default:
+		return false
+	}
+}
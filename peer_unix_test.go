@@ -0,0 +1,96 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestOwnConn(t *testing.T) {
+	l := listen(t, "tcp", "127.0.0.1:0")
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	lc, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lc.Close()
+
+	if !ownConn(lc) {
+		t.Errorf("connection of the current process is not recognized")
+	}
+}
+
+// TestAcceptRelayForeign checks that connection made by another process
+// is not relayed.
+func TestAcceptRelayForeign(t *testing.T) {
+	l := listen(t, "tcp", "127.0.0.1:0")
+	client, server := net.Pipe()
+	defer server.Close()
+	go acceptRelay(l, client)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperDial$")
+	cmd.Env = append(os.Environ(), "CHUBC_TEST_DIAL="+l.Addr().String())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s\n%s", err, out)
+	}
+
+	// Listener still waits for the connection of this process.
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	server.SetDeadline(time.Now().Add(5 * time.Second))
+	c.Write([]byte("x"))
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(server, buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestHelperDial connects to the address from CHUBC_TEST_DIAL
+// environment variable and expects connection to be closed. It is run
+// by TestAcceptRelayForeign in a separate process.
+func TestHelperDial(t *testing.T) {
+	addr := os.Getenv("CHUBC_TEST_DIAL")
+	if addr == "" {
+		t.Skip("helper process")
+	}
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = c.Read(make([]byte, 1))
+	if err != io.EOF {
+		t.Fatalf("connection is expected to be closed, got %v", err)
+	}
+}
@@ -41,6 +41,14 @@ func (c PingCommand) Args() (int, int) {
 	return 0, 0
 }
 
-func (c PingCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.Ping()
+func (c PingCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	err := ch.Ping()
+	if err != nil {
+		return err
+	}
+	if env.Output == OutputJSON {
+		return env.PrintJSON(map[string]bool{"ok": true})
+	}
+
+	return nil
 }
@@ -18,6 +18,8 @@
 package main
 
 import (
+	"math"
+
 	"github.com/vchimishuk/chubby"
 	"github.com/vchimishuk/opt"
 )
@@ -34,13 +36,41 @@ func (c PlayCommand) Name() string {
 }
 
 func (c PlayCommand) Options() []*opt.Desc {
-	return []*opt.Desc{}
+	return []*opt.Desc{
+		{"", "exact", opt.ArgNone, "",
+			"play exactly given path, do not match it fuzzily"},
+	}
 }
 
 func (c PlayCommand) Args() (int, int) {
-	return 1, 1
+	return 1, math.MaxInt
+}
+
+func (c PlayCommand) CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error) {
+	if n > 0 {
+		return nil, nil
+	}
+
+	return completeVFS(ch, prefix)
 }
 
-func (c PlayCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.Play(args[0])
+func (c PlayCommand) Interactive(opts opt.Options) bool {
+	return !opts.Has("exact")
+}
+
+func (c PlayCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	if opts.Has("exact") {
+		if len(args) > 1 {
+			return &usageError{c}
+		}
+		p := vfsPath(args[0])
+		return playPath(ch, p)
+	}
+
+	p, err := resolvePath(env, ch, args)
+	if err != nil {
+		return err
+	}
+
+	return playPath(ch, p)
 }
@@ -18,7 +18,6 @@
 package main
 
 import (
-	"fmt"
 	"sort"
 
 	"github.com/vchimishuk/chubby"
@@ -37,14 +36,18 @@ func (c PlaylistsCommand) Name() string {
 }
 
 func (c PlaylistsCommand) Options() []*opt.Desc {
-	return []*opt.Desc{}
+	return []*opt.Desc{templateOption()}
 }
 
 func (c PlaylistsCommand) Args() (int, int) {
 	return 0, 0
 }
 
-func (c PlaylistsCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
+func (c PlaylistsCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	t, err := parseTemplate(opts)
+	if err != nil {
+		return err
+	}
 	plists, err := ch.Playlists()
 	if err != nil {
 		return err
@@ -53,8 +56,26 @@ func (c PlaylistsCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []strin
 	sort.Slice(plists, func(i, j int) bool {
 		return plists[i].Name < plists[j].Name
 	})
+	if t != nil {
+		for _, pl := range plists {
+			err := printTemplate(env.Stdout, t, pl)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+	if env.Output == OutputJSON {
+		jpls := []*jsonPlaylist{}
+		for _, pl := range plists {
+			jpls = append(jpls, newJSONPlaylist(pl))
+		}
+
+		return env.PrintJSON(jpls)
+	}
 	for _, pl := range plists {
-		fmt.Printf("%s\n", pl.Name)
+		env.Printf("%s\n", pl.Name)
 	}
 
 	return nil
@@ -41,6 +41,6 @@ func (c PrevCommand) Args() (int, int) {
 	return 0, 0
 }
 
-func (c PrevCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.Prev()
+func (c PrevCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	return rejected(ch.Prev())
 }
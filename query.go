@@ -0,0 +1,188 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+type QueryCommand struct {
+}
+
+func NewQueryCommand() QueryCommand {
+	return QueryCommand{}
+}
+
+func (c QueryCommand) Name() string {
+	return "query"
+}
+
+func (c QueryCommand) Options() []*opt.Desc {
+	return []*opt.Desc{
+		{"f", "", opt.ArgString, "FORMAT", "result item format"},
+	}
+}
+
+func (c QueryCommand) Args() (int, int) {
+	return 1, 2
+}
+
+func (c QueryCommand) Local() bool {
+	return true
+}
+
+func (c QueryCommand) CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error) {
+	if n == 0 {
+		return filterPrefix([]string{"list", "play", "run"}, prefix), nil
+	}
+	if n > 1 {
+		return nil, nil
+	}
+	var names []string
+	if s := settings.Config.Section("queries", ""); s != nil {
+		for _, v := range s.Values {
+			names = append(names, v.Key)
+		}
+	}
+
+	return filterPrefix(names, prefix), nil
+}
+
+func (c QueryCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	switch args[0] {
+	case "list":
+		return c.list(env)
+	case "play", "run":
+		if len(args) != 2 {
+			return &usageError{c}
+		}
+		q, err := env.Settings.Config.Query(args[1])
+		if err != nil {
+			return err
+		}
+		idx, err := openIndex(env.Settings)
+		if err != nil {
+			return err
+		}
+		tracks := searchIndex(idx, nil, q)
+
+		if args[0] == "run" {
+			return printTracks(env, tracks, opts.StringOr("f", "%p%f"))
+		}
+		return c.play(env, ch, idx, tracks)
+	default:
+		return fmt.Errorf("unknown query command: %s", args[0])
+	}
+}
+
+// play starts playing all tracks matching query. Command is local since
+// list and run do not need server, so connection is established here
+// unless the command is chained with others which need it.
+func (c QueryCommand) play(env *Env, ch *chubby.Chubby, idx *Index,
+	tracks []*IndexTrack) error {
+
+	p, err := queryPlayPath(idx, tracks)
+	if err != nil {
+		return err
+	}
+	if ch == nil {
+		ch, err = connectTo(env.Settings)
+		if err != nil {
+			return err
+		}
+		defer ch.Close()
+	}
+
+	return playPath(ch, p)
+}
+
+func (c QueryCommand) list(env *Env) error {
+	var vals []ConfigValue
+	if s := env.Settings.Config.Section("queries", ""); s != nil {
+		vals = s.Values
+	}
+
+	if env.Output == OutputJSON {
+		qs := map[string]string{}
+		for _, v := range vals {
+			qs[v.Key] = v.Value
+		}
+
+		return env.PrintJSON(qs)
+	}
+	w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	for _, v := range vals {
+		fmt.Fprintf(w, "%s\t%s\n", v.Key, v.Value)
+	}
+
+	return w.Flush()
+}
+
+// queryPlayPath returns path which plays all the tracks and nothing
+// else. Server can play a single track or directory only, so tracks
+// must make up the whole indexed directory, including subdirectories.
+func queryPlayPath(idx *Index, tracks []*IndexTrack) (string, error) {
+	if len(tracks) == 0 {
+		return "", &notFoundError{"no tracks found"}
+	}
+	if len(tracks) == 1 {
+		return tracks[0].Path, nil
+	}
+
+	parents := map[string]string{}
+	owners := map[string]string{}
+	for p, d := range idx.Dirs {
+		for _, sd := range d.Dirs {
+			parents[sd] = p
+		}
+		for _, t := range d.Tracks {
+			owners[t.Path] = p
+		}
+	}
+	// Ancestors of the directory starting from the directory itself.
+	ancestors := func(dir string) []string {
+		as := []string{dir}
+		for p, ok := parents[dir]; ok; p, ok = parents[dir] {
+			as = append(as, p)
+			dir = p
+		}
+		return as
+	}
+
+	common := ancestors(owners[tracks[0].Path])
+	for _, t := range tracks[1:] {
+		as := map[string]bool{}
+		for _, a := range ancestors(owners[t.Path]) {
+			as[a] = true
+		}
+		for len(common) > 0 && !as[common[0]] {
+			common = common[1:]
+		}
+	}
+	if len(common) == 0 || idx.countTracks(common[0]) != len(tracks) {
+		return "", fmt.Errorf("%d matching tracks are not the whole "+
+			"contents of a directory, server can play a single "+
+			"track or directory only", len(tracks))
+	}
+
+	return common[0], nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestQueryPlayPath(t *testing.T) {
+	idx := &Index{Dirs: map[string]*IndexDir{
+		"/": {Dirs: []string{"/A", "/B"}},
+		"/A": {
+			Dirs:   []string{"/A/1", "/A/2"},
+			Tracks: []IndexTrack{{Path: "/A/intro.flac"}},
+		},
+		"/A/1": {Tracks: []IndexTrack{{Path: "/A/1/a.flac"},
+			{Path: "/A/1/b.flac"}}},
+		"/A/2": {Tracks: []IndexTrack{{Path: "/A/2/c.flac"}}},
+		"/B":   {Tracks: []IndexTrack{{Path: "/B/d.flac"}}},
+	}}
+	tests := []struct {
+		tracks []string
+		path   string
+	}{
+		{[]string{"/A/1/b.flac"}, "/A/1/b.flac"},
+		{[]string{"/A/1/a.flac", "/A/1/b.flac"}, "/A/1"},
+		{[]string{"/A/1/a.flac", "/A/1/b.flac", "/A/2/c.flac",
+			"/A/intro.flac"}, "/A"},
+		{[]string{"/A/1/a.flac", "/A/1/b.flac", "/A/2/c.flac",
+			"/A/intro.flac", "/B/d.flac"}, "/"},
+		{[]string{"/A/1/a.flac", "/A/2/c.flac"}, ""},
+		{[]string{"/A/1/a.flac", "/B/d.flac"}, ""},
+		{nil, ""},
+	}
+	for _, test := range tests {
+		var tracks []*IndexTrack
+		for _, p := range test.tracks {
+			tracks = append(tracks, &IndexTrack{Path: p})
+		}
+		p, err := queryPlayPath(idx, tracks)
+		if test.path == "" && err == nil {
+			t.Errorf("%v: error expected, got %q", test.tracks, p)
+		} else if test.path != "" && (err != nil || p != test.path) {
+			t.Errorf("%v: expected %q, got %q (%v)", test.tracks,
+				test.path, p, err)
+		}
+	}
+}
@@ -41,6 +41,14 @@ func (c RenamePlaylistCommand) Args() (int, int) {
 	return 2, 2
 }
 
-func (c RenamePlaylistCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.RenamePlaylist(args[0], args[1])
+func (c RenamePlaylistCommand) CompleteArg(ch *chubby.Chubby, n int, prefix string) ([]string, error) {
+	if n > 0 {
+		return nil, nil
+	}
+
+	return completePlaylists(ch, prefix)
+}
+
+func (c RenamePlaylistCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	return rejected(ch.RenamePlaylist(args[0], args[1]))
 }
@@ -0,0 +1,148 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+type ScrobbleDaemonCommand struct {
+}
+
+func NewScrobbleDaemonCommand() ScrobbleDaemonCommand {
+	return ScrobbleDaemonCommand{}
+}
+
+func (c ScrobbleDaemonCommand) Name() string {
+	return "scrobble-daemon"
+}
+
+func (c ScrobbleDaemonCommand) Options() []*opt.Desc {
+	return []*opt.Desc{reconnectOption()}
+}
+
+func (c ScrobbleDaemonCommand) Args() (int, int) {
+	return 0, 0
+}
+
+func (c ScrobbleDaemonCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+func (c ScrobbleDaemonCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	stream, err := openEventStream(env.Settings, ch, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	sc := &scrobbler{settings: env.Settings}
+	st, err := stream.Chubby().Status()
+	if err != nil {
+		return err
+	}
+	sc.update(&st, time.Now())
+	for {
+		select {
+		case e := <-stream.C:
+			if e == nil {
+				err := sc.record(nil)
+				if err != nil {
+					return err
+				}
+				return errConnLost
+			}
+			if e.Event() == EventDisconnected {
+				err = sc.record(nil)
+			} else {
+				var ok bool
+				st, ok, err = stream.Status()
+				if err == nil && ok {
+					err = sc.record(&st)
+				} else if err == nil {
+					err = sc.record(nil)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		case <-sigs:
+			return sc.record(nil)
+		}
+	}
+}
+
+// scrobbler tracks currently playing track and time it was listened for.
+type scrobbler struct {
+	settings *Settings
+	cur      *HistoryRecord
+	listened time.Duration
+	playing  bool
+	updated  time.Time
+}
+
+// record updates scrobbler with the current status and appends finished
+// track to the history. Nil status finishes current track.
+func (s *scrobbler) record(st *chubby.Status) error {
+	r := s.update(st, time.Now())
+	if r == nil {
+		return nil
+	}
+
+	return appendTrackHistory(r)
+}
+
+// update accounts time track was played for since the previous update
+// and switches current track if status refers to another one. Finished
+// track record is returned.
+func (s *scrobbler) update(st *chubby.Status, now time.Time) *HistoryRecord {
+	if s.playing {
+		s.listened += now.Sub(s.updated)
+	}
+	s.updated = now
+	stopped := st == nil || st.State == chubby.StateStopped
+
+	var done *HistoryRecord
+	if s.cur != nil && (stopped || st.Track.Path != s.cur.Path) {
+		done = s.cur
+		done.Listened = int(s.listened.Seconds())
+		if done.Length > 0 {
+			done.Listened = min(done.Listened, done.Length)
+		}
+		done.Skipped = !scrobbleable(done.Listened, done.Length)
+		s.cur = nil
+	}
+	if !stopped && s.cur == nil {
+		start := now.Add(-time.Duration(seconds(st.TrackPos)) * time.Second)
+		s.cur = newHistoryRecord(s.settings, st.Track, start)
+		s.listened = 0
+	}
+	s.playing = !stopped && st.State == chubby.StatePlaying
+
+	return done
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+type SearchCommand struct {
+}
+
+func NewSearchCommand() SearchCommand {
+	return SearchCommand{}
+}
+
+func (c SearchCommand) Name() string {
+	return "search"
+}
+
+func (c SearchCommand) Options() []*opt.Desc {
+	return []*opt.Desc{
+		{"f", "", opt.ArgString, "FORMAT", "result item format"},
+		{"n", "", opt.ArgInt, "NUMBER", "maximum number of results"},
+		{"q", "query", opt.ArgString, "EXPR",
+			"filter results with query expression"},
+	}
+}
+
+func (c SearchCommand) Args() (int, int) {
+	return 0, math.MaxInt
+}
+
+func (c SearchCommand) Local() bool {
+	return true
+}
+
+func (c SearchCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	var q *Query
+	var err error
+	if opts.Has("query") {
+		q, err = ParseQuery(opts.StringOr("query", ""))
+		if err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+	}
+	terms := strings.Fields(fold(strings.Join(args, " ")))
+	if len(terms) == 0 && q == nil {
+		return errors.New("search terms or query expected")
+	}
+
+	idx, err := openIndex(env.Settings)
+	if err != nil {
+		return err
+	}
+	tracks := searchIndex(idx, terms, q)
+	if n := opts.IntOr("n", 0); n > 0 && n < len(tracks) {
+		tracks = tracks[:n]
+	}
+
+	return printTracks(env, tracks, opts.StringOr("f", "%p%f"))
+}
+
+// openIndex loads index of the server.
+func openIndex(s *Settings) (*Index, error) {
+	idx, err := loadIndex(s)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("index for %s not found, "+
+			"run `index update` first", serverName(s))
+	}
+
+	return idx, err
+}
+
+// searchIndex returns indexed tracks matching all search terms and
+// the query, if it is not nil. Tracks are ordered by relevance, or by path
+// if there are no search terms.
+func searchIndex(idx *Index, terms []string, q *Query) []*IndexTrack {
+	type result struct {
+		track *IndexTrack
+		score float64
+	}
+	var results []result
+	idx.Tracks(func(t *IndexTrack) {
+		if q != nil && !q.Match(newIndexQueryRecord(t)) {
+			return
+		}
+		var s float64 = 1
+		if len(terms) > 0 {
+			s = searchScore(t, terms)
+		}
+		if s > 0 {
+			results = append(results, result{t, s})
+		}
+	})
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].track.Path < results[j].track.Path
+	})
+
+	var tracks []*IndexTrack
+	for _, r := range results {
+		tracks = append(tracks, r.track)
+	}
+
+	return tracks
+}
+
+func printTracks(env *Env, tracks []*IndexTrack, fs string) error {
+	if env.Output == OutputJSON {
+		jents := []*jsonEntry{}
+		for _, t := range tracks {
+			jents = append(jents, t.JSON())
+		}
+
+		return env.PrintJSON(jents)
+	}
+	f, err := ParseFormat(fs)
+	if err != nil {
+		return fmt.Errorf("format: %w", err)
+	}
+	for _, t := range tracks {
+		env.Println(f.Format(t.Vars()))
+	}
+
+	return nil
+}
+
+// searchScore returns rank of the track for the search terms. Every
+// term has to match at least one track field, otherwise zero is returned.
+// Matches in title weight more than matches in artist, album, year
+// or file name. Whole field matches weight more than matches at
+// the beginning of a word, which weight more than arbitrary substrings.
+func searchScore(t *IndexTrack, terms []string) float64 {
+	fields := []struct {
+		value  string
+		weight float64
+	}{
+		{fold(t.Title), 3},
+		{fold(t.Artist), 2.5},
+		{fold(t.Album), 2},
+		{strconv.Itoa(t.Year), 1.5},
+		{fold(path.Base(t.Path)), 1},
+	}
+
+	var score float64 = 0
+	for _, term := range terms {
+		var best float64 = 0
+		for _, f := range fields {
+			best = max(best, matchScore(f.value, term)*f.weight)
+		}
+		if best == 0 {
+			return 0
+		}
+		score += best
+	}
+
+	return score
+}
+
+func matchScore(s string, term string) float64 {
+	if s == term {
+		return 4
+	}
+
+	var score float64 = 0
+	for i := 0; i <= len(s)-len(term); {
+		j := strings.Index(s[i:], term)
+		if j < 0 {
+			break
+		}
+		i += j
+		score = 1
+		r, _ := utf8.DecodeLastRuneInString(s[:i])
+		if i == 0 || !isWordRune(r) {
+			return 2
+		}
+		i++
+	}
+
+	return score
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
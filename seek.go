@@ -44,7 +44,7 @@ func (c SeekCommand) Args() (int, int) {
 	return 1, 1
 }
 
-func (c SeekCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
+func (c SeekCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
 	var st string
 	var mod chubby.SeekMode
 	if args[0][0] == '-' {
@@ -63,5 +63,5 @@ func (c SeekCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) er
 		return errors.New("invalid time format")
 	}
 
-	return ch.Seek(t, mod)
+	return rejected(ch.Seek(t, mod))
 }
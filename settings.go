@@ -0,0 +1,258 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/vchimishuk/opt"
+)
+
+const (
+	DefaultHost   = "localhost"
+	DefaultPort   = 5115
+	DefaultFormat = "%f%/"
+	// Connection and command timeout, zero disables it.
+	DefaultTimeout = 10 * time.Second
+	// Number of connection retries.
+	DefaultRetries = 0
+	// SOCKS5 proxy port used if proxy address does not contain one.
+	DefaultSocks5Port = 1080
+)
+
+// Setting is a single resolved configuration value along with
+// a human readable description of where the value came from.
+type Setting struct {
+	Value  string
+	Source string
+}
+
+// Settings holds effective client configuration resolved from command line
+// options, environment variables, configuration file and defaults,
+// in that order of precedence.
+type Settings struct {
+	Config       *Config
+	Profile      Setting
+	Host         Setting
+	Port         Setting
+	Format       Setting
+	Output       Setting
+	Timeout      Setting
+	Retries      Setting
+	ProxyCommand Setting
+	Socks5       Setting
+}
+
+// Effective settings used by the current process.
+var settings *Settings = &Settings{
+	Host:    Setting{DefaultHost, "default"},
+	Port:    Setting{strconv.Itoa(DefaultPort), "default"},
+	Format:  Setting{DefaultFormat, "default"},
+	Output:  Setting{OutputText, "default"},
+	Timeout: Setting{DefaultTimeout.String(), "default"},
+	Retries: Setting{strconv.Itoa(DefaultRetries), "default"},
+}
+
+func (s *Settings) PortNumber() int {
+	p, _ := strconv.Atoi(s.Port.Value)
+
+	return p
+}
+
+// Socks5Addr returns SOCKS5 proxy address.
+func (s *Settings) Socks5Addr() serverAddr {
+	a, _ := parseServerAddr(s.Socks5.Value, DefaultSocks5Port)
+
+	return a
+}
+
+// ServerAddr returns server address made of host and port settings.
+func (s *Settings) ServerAddr() serverAddr {
+	a, _ := parseServerAddr(s.Host.Value, s.PortNumber())
+
+	return a
+}
+
+func (s *Settings) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(s.Timeout.Value)
+
+	return d
+}
+
+func (s *Settings) RetriesNumber() int {
+	n, _ := strconv.Atoi(s.Retries.Value)
+
+	return n
+}
+
+// ConnectionArgs returns global options which make another process
+// connect to the same server the same way.
+func (s *Settings) ConnectionArgs() []string {
+	var args []string
+	if s.Profile.Value != "" {
+		args = append(args, "--profile", s.Profile.Value)
+	}
+	args = append(args, "-h", s.Host.Value, "-p", s.Port.Value,
+		"--timeout", s.Timeout.Value, "--retries", s.Retries.Value)
+	if s.ProxyCommand.Value != "" {
+		args = append(args, "--proxy-command", s.ProxyCommand.Value)
+	}
+	if s.Socks5.Value != "" {
+		args = append(args, "--socks5", s.Socks5.Value)
+	}
+
+	return args
+}
+
+func resolveSettings(opts opt.Options) (*Settings, error) {
+	return resolveProfileSettings(opts, "")
+}
+
+// resolveProfileSettings resolves settings of the profile if it is not
+// empty. In this case server address is taken from the profile or
+// top-level configuration section only.
+func resolveProfileSettings(opts opt.Options, profile string) (*Settings, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	s := &Settings{Config: cfg}
+	top := cfg.Sections[0]
+	flags := map[string]string{}
+	for _, name := range []string{"profile", "host", "output", "timeout",
+		"proxy-command", "socks5"} {
+		if opts.Has(name) {
+			flags[name] = opts.StringOr(name, "")
+		}
+	}
+	if opts.Has("port") {
+		flags["port"] = strconv.Itoa(opts.IntOr("port", 0))
+	}
+	if opts.Has("retries") {
+		flags["retries"] = strconv.Itoa(opts.IntOr("retries", 0))
+	}
+	hostEnv, portEnv := "CHUBC_HOST", "CHUBC_PORT"
+	if profile != "" {
+		flags["profile"] = profile
+		delete(flags, "host")
+		delete(flags, "port")
+		hostEnv, portEnv = "", ""
+	}
+
+	s.Profile = lookupSetting(flags, "profile", "CHUBC_PROFILE", cfg, top,
+		nil, "profile", "")
+	var prof *ConfigSection
+	if s.Profile.Value != "" {
+		prof = cfg.Section("profile", s.Profile.Value)
+		if prof == nil {
+			return nil, fmt.Errorf("profile %q not found in %s",
+				s.Profile.Value, cfg.Path)
+		}
+	}
+
+	s.Host = lookupSetting(flags, "host", hostEnv, cfg, top,
+		prof, "host", DefaultHost)
+	s.Port = lookupSetting(flags, "port", portEnv, cfg, top,
+		prof, "port", strconv.Itoa(DefaultPort))
+	s.Format = lookupSetting(flags, "", "", cfg, top,
+		prof, "format", DefaultFormat)
+	s.Output = lookupSetting(flags, "output", "", cfg, top,
+		prof, "output", OutputText)
+	s.Timeout = lookupSetting(flags, "timeout", "CHUBC_TIMEOUT", cfg, top,
+		prof, "timeout", DefaultTimeout.String())
+	s.Retries = lookupSetting(flags, "retries", "CHUBC_RETRIES", cfg, top,
+		prof, "retries", strconv.Itoa(DefaultRetries))
+	s.ProxyCommand = lookupSetting(flags, "proxy-command",
+		"CHUBC_PROXY_COMMAND", cfg, top, prof, "proxy-command", "")
+	s.Socks5 = lookupSetting(flags, "socks5", "CHUBC_SOCKS5", cfg, top,
+		prof, "socks5", "")
+
+	p, err := strconv.Atoi(s.Port.Value)
+	if err != nil || p <= 0 || p > 65535 {
+		return nil, fmt.Errorf("invalid port number: %s (%s)",
+			s.Port.Value, s.Port.Source)
+	}
+	if _, err := parseServerAddr(s.Host.Value, p); err != nil {
+		return nil, fmt.Errorf("invalid server address: %s: %s (%s)",
+			s.Host.Value, err, s.Host.Source)
+	}
+	if s.Output.Value != OutputText && s.Output.Value != OutputJSON {
+		return nil, fmt.Errorf("invalid output mode: %s (%s)",
+			s.Output.Value, s.Output.Source)
+	}
+	d, err := time.ParseDuration(s.Timeout.Value)
+	if err != nil || d < 0 {
+		return nil, fmt.Errorf("invalid timeout: %s (%s)",
+			s.Timeout.Value, s.Timeout.Source)
+	}
+	r, err := strconv.Atoi(s.Retries.Value)
+	if err != nil || r < 0 {
+		return nil, fmt.Errorf("invalid retries number: %s (%s)",
+			s.Retries.Value, s.Retries.Source)
+	}
+	if s.ProxyCommand.Value != "" && s.Socks5.Value != "" {
+		return nil, fmt.Errorf("proxy command (%s) and SOCKS5 proxy "+
+			"(%s) can not be used together", s.ProxyCommand.Source,
+			s.Socks5.Source)
+	}
+	if s.Socks5.Value != "" {
+		a, err := parseServerAddr(s.Socks5.Value, DefaultSocks5Port)
+		if err == nil && a.Network != "tcp" {
+			err = errors.New("TCP address expected")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOCKS5 proxy address: "+
+				"%s: %s (%s)", s.Socks5.Value, err, s.Socks5.Source)
+		}
+	}
+
+	return s, nil
+}
+
+// lookupSetting searches for the setting value in command line flags,
+// environment, selected profile and top-level configuration section.
+// Empty option or environment variable name disables corresponding lookup.
+func lookupSetting(flags map[string]string, optName string, env string,
+	cfg *Config, top *ConfigSection, prof *ConfigSection, key string,
+	def string) Setting {
+
+	if v, ok := flags[optName]; ok && optName != "" {
+		return Setting{v, "command line (--" + optName + ")"}
+	}
+	if env != "" {
+		if v, ok := os.LookupEnv(env); ok {
+			return Setting{v, "environment (" + env + ")"}
+		}
+	}
+	if prof != nil {
+		if v, ok := prof.Get(key); ok {
+			return Setting{v.Value, fmt.Sprintf("profile %s (%s:%d)",
+				prof.Name, cfg.Path, v.Line)}
+		}
+	}
+	if v, ok := top.Get(key); ok {
+		return Setting{v.Value, fmt.Sprintf("config (%s:%d)",
+			cfg.Path, v.Line)}
+	}
+
+	return Setting{def, "default"}
+}
@@ -0,0 +1,202 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+type ShellCommand struct {
+}
+
+func NewShellCommand() ShellCommand {
+	return ShellCommand{}
+}
+
+func (c ShellCommand) Name() string {
+	return "shell"
+}
+
+func (c ShellCommand) Options() []*opt.Desc {
+	return []*opt.Desc{}
+}
+
+func (c ShellCommand) Args() (int, int) {
+	return 0, 0
+}
+
+func (c ShellCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+func (c ShellCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	orig := ch
+	defer func() {
+		if ch != orig {
+			ch.Close()
+		}
+	}()
+	hist := loadHistory()
+	ed := NewLineEditor(hist)
+
+	for {
+		line, err := ed.ReadLine(fmt.Sprintf("chubc:%s> ", workDir))
+		if errors.Is(err, errInterrupted) {
+			continue
+		} else if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		ed.AddHistory(line)
+		appendHistory(line)
+
+		args, err := splitArgs(line)
+		if err != nil {
+			env.PrintError("%s", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "help":
+			shellHelp(env, args[1:])
+		case "pwd":
+			env.Println(workDir)
+		case "cd":
+			err = shellCd(ch, args[1:])
+		case c.Name(), "tui":
+			err = fmt.Errorf("%s is not available in shell", args[0])
+		default:
+			err = execCommand(env, ch, args)
+		}
+		var uerr *usageError
+		if errors.As(err, &uerr) {
+			printCommandUsage(env, uerr.cmd)
+		} else if err != nil {
+			env.PrintError("%s", err)
+		}
+		ch = redial(env, ch, err)
+	}
+}
+
+func shellCd(ch *chubby.Chubby, args []string) error {
+	if len(args) > 1 {
+		return errors.New("too many arguments")
+	}
+	dir := "/"
+	if len(args) == 1 {
+		dir = path.Clean(vfsPath(args[0]))
+	}
+	_, err := ch.List(dir)
+	if err != nil {
+		return err
+	}
+	workDir = dir
+
+	return nil
+}
+
+func shellHelp(env *Env, args []string) {
+	if len(args) > 0 {
+		cmd := command(args[0])
+		if cmd == nil {
+			env.PrintError("unknown command: %s", args[0])
+		} else {
+			printCommandUsage(env, cmd)
+		}
+		return
+	}
+
+	env.Printf("Shell commands:\n")
+	env.Printf("  cd [PATH]  Change current VFS directory.\n")
+	env.Printf("  exit       Exit shell.\n")
+	env.Printf("  help [COMMAND]\n")
+	env.Printf("             Show help.\n")
+	env.Printf("  pwd        Print current VFS directory.\n")
+	env.Printf("\n")
+	env.Printf("Commands:\n")
+	for _, cmd := range Commands {
+		if cmd.Name() != "shell" {
+			env.Printf("  %s\n", cmd.Name())
+		}
+	}
+}
+
+func historyPath() string {
+	return filepath.Join(stateHome(), "chubc", "history")
+}
+
+func loadHistory() []string {
+	var hist []string
+	f, err := os.Open(historyPath())
+	if err != nil {
+		return hist
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		hist = append(hist, sc.Text())
+	}
+	// History file is only appended to, so truncate it
+	// from time to time to keep its size reasonable.
+	if len(hist) > 2*historySize {
+		hist = hist[len(hist)-historySize:]
+		os.WriteFile(historyPath(),
+			[]byte(strings.Join(hist, "\n")+"\n"), 0600)
+	}
+	if len(hist) > historySize {
+		hist = hist[len(hist)-historySize:]
+	}
+
+	return hist
+}
+
+// appendHistory saves the line to the history file. Failures are
+// silently ignored, since history is not essential for shell operation.
+func appendHistory(line string) {
+	p := historyPath()
+	err := os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
@@ -0,0 +1,463 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+// Report sections.
+const (
+	StatsArtists   = "artists"
+	StatsAlbums    = "albums"
+	StatsTracks    = "tracks"
+	StatsTime      = "time"
+	StatsSkips     = "skips"
+	StatsForgotten = "forgotten"
+)
+
+var statsSections = []string{StatsArtists, StatsAlbums, StatsTracks,
+	StatsTime, StatsSkips, StatsForgotten}
+
+const (
+	// Default number of entries in top lists.
+	DefaultStatsTop = 10
+	// Track is a forgotten favorite if it was listened at least
+	// forgottenMinPlays times and was not played for forgottenPeriod.
+	forgottenMinPlays = 3
+	forgottenPeriod   = 90 * 24 * time.Hour
+)
+
+type StatsCommand struct {
+}
+
+func NewStatsCommand() StatsCommand {
+	return StatsCommand{}
+}
+
+func (c StatsCommand) Name() string {
+	return "stats"
+}
+
+func (c StatsCommand) Options() []*opt.Desc {
+	return []*opt.Desc{
+		{"", "by", opt.ArgString, "KEY",
+			"order top lists by plays or time"},
+		{"", "csv", opt.ArgNone, "", "print report as CSV"},
+		{"n", "", opt.ArgInt, "NUMBER",
+			"number of entries in every section"},
+		{"p", "period", opt.ArgString, "PERIOD",
+			"listening time period: day, week or month"},
+		{"s", "section", opt.ArgString, "NAME[,NAME]",
+			"print only given report sections"},
+		{"", "since", opt.ArgString, "DATE",
+			"count tracks played at or after DATE"},
+		{"", "until", opt.ArgString, "DATE",
+			"count tracks played before or at DATE"},
+	}
+}
+
+func (c StatsCommand) Args() (int, int) {
+	return 0, 0
+}
+
+func (c StatsCommand) Local() bool {
+	return true
+}
+
+func (c StatsCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	by := opts.StringOr("by", "plays")
+	if by != "plays" && by != "time" {
+		return fmt.Errorf("invalid order key: %s", by)
+	}
+	period := opts.StringOr("period", "day")
+	if _, ok := statsPeriods[period]; !ok {
+		return fmt.Errorf("invalid period: %s", period)
+	}
+	sections := statsSections
+	if opts.Has("section") {
+		sections = strings.Split(opts.StringOr("section", ""), ",")
+		for _, s := range sections {
+			if !slices.Contains(statsSections, s) {
+				return fmt.Errorf("unknown section: %s", s)
+			}
+		}
+	}
+	n := opts.IntOr("n", DefaultStatsTop)
+	filter, err := newHistoryFilter(opts)
+	if err != nil {
+		return err
+	}
+	// Forgotten favorites are looked for before the window, so the
+	// whole history is loaded.
+	all, err := loadTrackHistory(nil)
+	if err != nil {
+		return err
+	}
+	var recs []*HistoryRecord
+	for _, r := range all {
+		if filter.Match(r) {
+			recs = append(recs, r)
+		}
+	}
+
+	var tables []*statsTable
+	for _, s := range sections {
+		var t *statsTable
+		switch s {
+		case StatsArtists:
+			t = topStats(s, recs, by, n, func(r *HistoryRecord) []string {
+				return []string{r.Artist}
+			}, "artist")
+		case StatsAlbums:
+			t = topStats(s, recs, by, n, func(r *HistoryRecord) []string {
+				return []string{r.Artist, r.Album}
+			}, "artist", "album")
+		case StatsTracks:
+			t = topStats(s, recs, by, n, func(r *HistoryRecord) []string {
+				return []string{r.Artist, r.Title, r.Path}
+			}, "artist", "title", "path")
+		case StatsTime:
+			t = timeStats(recs, period)
+		case StatsSkips:
+			t = skipStats(recs, n)
+		case StatsForgotten:
+			cutoff := filter.since
+			if cutoff.IsZero() {
+				cutoff = time.Now().Add(-forgottenPeriod)
+			}
+			t = forgottenStats(all, cutoff, filter.until, n)
+		}
+		tables = append(tables, t)
+	}
+
+	if env.Output == OutputJSON {
+		m := map[string][]map[string]interface{}{}
+		for _, t := range tables {
+			m[t.name] = t.objects()
+		}
+		return env.PrintJSON(m)
+	}
+	if opts.Has("csv") {
+		return printStatsCSV(env, tables)
+	}
+
+	return printStatsText(env, tables)
+}
+
+// statsDuration is a number of seconds printed as [h:]mm:ss in text
+// report.
+type statsDuration int
+
+// statsRate is a fraction printed as a percentage in text report.
+type statsRate float64
+
+// statsTable is a single report section. Row values are strings, ints,
+// statsDuration or statsRate.
+type statsTable struct {
+	name    string
+	columns []string
+	rows    [][]interface{}
+}
+
+func (t *statsTable) objects() []map[string]interface{} {
+	objs := []map[string]interface{}{}
+	for _, r := range t.rows {
+		o := map[string]interface{}{}
+		for i, c := range t.columns {
+			switch v := r[i].(type) {
+			case statsDuration:
+				o[c] = int(v)
+			case statsRate:
+				o[c] = float64(v)
+			default:
+				o[c] = v
+			}
+		}
+		objs = append(objs, o)
+	}
+
+	return objs
+}
+
+// statsEntry accumulates plays of a single artist, album or track.
+type statsEntry struct {
+	key      []string
+	plays    int
+	skips    int
+	listened int
+	last     time.Time
+}
+
+// groupStats groups records by the key returned by fn. Entries are
+// returned in order of their first appearance.
+func groupStats(recs []*HistoryRecord,
+	fn func(r *HistoryRecord) []string) []*statsEntry {
+
+	idx := map[string]*statsEntry{}
+	var entries []*statsEntry
+	for _, r := range recs {
+		key := fn(r)
+		k := strings.Join(key, "\x00")
+		e, ok := idx[k]
+		if !ok {
+			e = &statsEntry{key: key}
+			idx[k] = e
+			entries = append(entries, e)
+		}
+		if r.Skipped {
+			e.skips++
+		} else {
+			e.plays++
+		}
+		e.listened += r.Listened
+		if r.Time.After(e.last) {
+			e.last = r.Time
+		}
+	}
+
+	return entries
+}
+
+// topStats returns top n entries grouped by fn ordered by number of
+// listened plays or by time listened.
+func topStats(name string, recs []*HistoryRecord, by string, n int,
+	fn func(r *HistoryRecord) []string, columns ...string) *statsTable {
+
+	entries := groupStats(recs, fn)
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if by == "time" && a.listened != b.listened {
+			return a.listened > b.listened
+		}
+		if a.plays != b.plays {
+			return a.plays > b.plays
+		}
+		return a.listened > b.listened
+	})
+
+	t := &statsTable{
+		name:    name,
+		columns: append(columns, "plays", "time"),
+	}
+	for _, e := range entries {
+		if n > 0 && len(t.rows) == n {
+			break
+		}
+		if e.plays == 0 && e.listened == 0 {
+			continue
+		}
+		var row []interface{}
+		for _, k := range e.key {
+			row = append(row, k)
+		}
+		row = append(row, e.plays, statsDuration(e.listened))
+		t.rows = append(t.rows, row)
+	}
+
+	return t
+}
+
+// statsPeriods maps listening time period names to functions returning
+// period the time belongs to.
+var statsPeriods = map[string]func(t time.Time) string{
+	"day": func(t time.Time) string {
+		return t.Format("2006-01-02")
+	},
+	"week": func(t time.Time) string {
+		y, w := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	},
+	"month": func(t time.Time) string {
+		return t.Format("2006-01")
+	},
+}
+
+// timeStats returns time listened per period in chronological order.
+func timeStats(recs []*HistoryRecord, period string) *statsTable {
+	fn := statsPeriods[period]
+	entries := groupStats(recs, func(r *HistoryRecord) []string {
+		return []string{fn(r.Time.Local())}
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].key[0] < entries[j].key[0]
+	})
+
+	t := &statsTable{
+		name:    StatsTime,
+		columns: []string{period, "plays", "time"},
+	}
+	for _, e := range entries {
+		t.rows = append(t.rows, []interface{}{e.key[0], e.plays,
+			statsDuration(e.listened)})
+	}
+
+	return t
+}
+
+// skipStats returns n albums with the highest skip rate.
+func skipStats(recs []*HistoryRecord, n int) *statsTable {
+	entries := groupStats(recs, func(r *HistoryRecord) []string {
+		return []string{r.Artist, r.Album}
+	})
+	rate := func(e *statsEntry) float64 {
+		return float64(e.skips) / float64(e.plays+e.skips)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if rate(a) != rate(b) {
+			return rate(a) > rate(b)
+		}
+		return a.skips > b.skips
+	})
+
+	t := &statsTable{
+		name: StatsSkips,
+		columns: []string{"artist", "album", "plays", "skips",
+			"rate"},
+	}
+	for _, e := range entries {
+		if n > 0 && len(t.rows) == n || e.skips == 0 {
+			break
+		}
+		t.rows = append(t.rows, []interface{}{e.key[0], e.key[1],
+			e.plays, e.skips, statsRate(rate(e))})
+	}
+
+	return t
+}
+
+// forgottenStats returns n most played tracks which were listened at
+// least forgottenMinPlays times before cutoff and were not played
+// after it. Records after until are ignored if until is not zero.
+func forgottenStats(recs []*HistoryRecord, cutoff time.Time,
+	until time.Time, n int) *statsTable {
+
+	var before []*HistoryRecord
+	recent := map[string]bool{}
+	for _, r := range recs {
+		if !until.IsZero() && r.Time.After(until) {
+			continue
+		}
+		if r.Time.Before(cutoff) {
+			before = append(before, r)
+		} else {
+			recent[r.Path] = true
+		}
+	}
+	entries := groupStats(before, func(r *HistoryRecord) []string {
+		return []string{r.Artist, r.Title, r.Path}
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].plays > entries[j].plays
+	})
+
+	t := &statsTable{
+		name: StatsForgotten,
+		columns: []string{"artist", "title", "path", "plays",
+			"last_played"},
+	}
+	for _, e := range entries {
+		if n > 0 && len(t.rows) == n || e.plays < forgottenMinPlays {
+			break
+		}
+		if recent[e.key[2]] {
+			continue
+		}
+		t.rows = append(t.rows, []interface{}{e.key[0], e.key[1],
+			e.key[2], e.plays, e.last.Local().Format("2006-01-02")})
+	}
+
+	return t
+}
+
+func printStatsText(env *Env, tables []*statsTable) error {
+	for i, t := range tables {
+		if i > 0 {
+			env.Println()
+		}
+		env.Printf("%s:\n", t.name)
+		if len(t.rows) == 0 {
+			env.Println("  no data")
+			continue
+		}
+		w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  %s\n", strings.ToUpper(
+			strings.Join(t.columns, "\t")))
+		for _, r := range t.rows {
+			vals := make([]string, len(r))
+			for j, v := range r {
+				switch v := v.(type) {
+				case statsDuration:
+					vals[j] = formatSeconds(int(v))
+				case statsRate:
+					vals[j] = fmt.Sprintf("%.0f%%", float64(v)*100)
+				default:
+					vals[j] = fmt.Sprint(v)
+				}
+			}
+			fmt.Fprintf(w, "  %s\n", strings.Join(vals, "\t"))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printStatsCSV prints every section as a separate CSV table with
+// a header row. Tables are separated with an empty line.
+func printStatsCSV(env *Env, tables []*statsTable) error {
+	for i, t := range tables {
+		if i > 0 {
+			env.Println()
+		}
+		w := csv.NewWriter(env.Stdout)
+		w.Write(t.columns)
+		for _, r := range t.rows {
+			vals := make([]string, len(r))
+			for j, v := range r {
+				switch v := v.(type) {
+				case statsRate:
+					vals[j] = strconv.FormatFloat(float64(v),
+						'f', 3, 64)
+				default:
+					vals[j] = fmt.Sprint(v)
+				}
+			}
+			w.Write(vals)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -18,8 +18,6 @@
 package main
 
 import (
-	"fmt"
-
 	"github.com/vchimishuk/chubby"
 	"github.com/vchimishuk/opt"
 )
@@ -36,34 +34,56 @@ func (c StatusCommand) Name() string {
 }
 
 func (c StatusCommand) Options() []*opt.Desc {
-	return []*opt.Desc{}
+	return []*opt.Desc{
+		templateOption(),
+		{"w", "watch", opt.ArgNone, "",
+			"keep running and print status on every change"},
+		reconnectOption(),
+	}
 }
 
 func (c StatusCommand) Args() (int, int) {
 	return 0, 0
 }
 
-func (c StatusCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
+func (c StatusCommand) LongRunning(opts opt.Options) bool {
+	return opts.Has("watch")
+}
+
+func (c StatusCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	t, err := parseTemplate(opts)
+	if err != nil {
+		return err
+	}
+	if opts.Has("watch") {
+		return watchStatus(env, ch, opts, t)
+	}
 	s, err := ch.Status()
 	if err != nil {
 		return err
 	}
+	if t != nil {
+		return printTemplate(env.Stdout, t, s)
+	}
+	if env.Output == OutputJSON {
+		return env.PrintJSON(newJSONStatus(s))
+	}
 
-	fmt.Printf("State: %s\n", s.State)
-	fmt.Printf("Volume: %d\n", s.Volume)
+	env.Printf("State: %s\n", s.State)
+	env.Printf("Volume: %d\n", s.Volume)
 	if s.State != chubby.StateStopped {
-		fmt.Printf("Playlist name: %s\n", s.Playlist.Name)
-		fmt.Printf("Playlist position: %d\n", s.PlaylistPos+1)
-		fmt.Printf("Playlist length: %d\n", s.Playlist.Length)
-		fmt.Printf("Playlist duration: %s\n", s.Playlist.Duration)
-		fmt.Printf("Track path: %s\n", s.Track.Path)
-		fmt.Printf("Track duration: %s\n", s.Track.Length)
-		fmt.Printf("Track position: %s\n", s.TrackPos)
-		fmt.Printf("Track artist: %s\n", s.Track.Artist)
-		fmt.Printf("Track album: %s\n", s.Track.Album)
-		fmt.Printf("Track title: %s\n", s.Track.Title)
-		fmt.Printf("Track year: %d\n", s.Track.Year)
-		fmt.Printf("Track number: %d\n", s.Track.Number)
+		env.Printf("Playlist name: %s\n", s.Playlist.Name)
+		env.Printf("Playlist position: %d\n", s.PlaylistPos+1)
+		env.Printf("Playlist length: %d\n", s.Playlist.Length)
+		env.Printf("Playlist duration: %s\n", s.Playlist.Duration)
+		env.Printf("Track path: %s\n", s.Track.Path)
+		env.Printf("Track duration: %s\n", s.Track.Length)
+		env.Printf("Track position: %s\n", s.TrackPos)
+		env.Printf("Track artist: %s\n", s.Track.Artist)
+		env.Printf("Track album: %s\n", s.Track.Album)
+		env.Printf("Track title: %s\n", s.Track.Title)
+		env.Printf("Track year: %d\n", s.Track.Year)
+		env.Printf("Track number: %d\n", s.Track.Number)
 	}
 
 	return nil
@@ -41,6 +41,6 @@ func (c StopCommand) Args() (int, int) {
 	return 0, 0
 }
 
-func (c StopCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
-	return ch.Stop()
+func (c StopCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	return rejected(ch.Stop())
 }
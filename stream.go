@@ -0,0 +1,294 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+// Names of synthetic events sent by eventStream when connection
+// to the server is lost and when it is established again.
+const (
+	EventDisconnected = "disconnected"
+	EventReconnected  = "reconnected"
+)
+
+// Reconnection delay limits.
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// errConnLost is returned by long-running commands when connection
+// to the server is lost and it is not going to be reestablished.
+var errConnLost = errors.New("connection to the server lost")
+
+// Event is a server event or a synthetic event generated by the client.
+type Event interface {
+	Event() string
+	Serialize() string
+}
+
+type clientEvent struct {
+	name string
+	data string
+}
+
+func (e *clientEvent) Event() string {
+	return e.name
+}
+
+func (e *clientEvent) Serialize() string {
+	return e.data
+}
+
+// eventStream receives server events over a dedicated connection.
+// If connection is lost, eventStream reconnects to the server with
+// exponential backoff, replaces command connection with a new one and
+// sends synthetic disconnected and reconnected events.
+type eventStream struct {
+	// Events channel. It is closed when connection is lost and
+	// reconnection is disabled.
+	C         <-chan Event
+	out       chan Event
+	done      chan bool
+	reconnect bool
+	settings  *Settings
+	mu        sync.Mutex
+	// Command connection and the one stream was opened with.
+	ch     *chubby.Chubby
+	orig   *chubby.Chubby
+	evch   *chubby.Chubby
+	events <-chan chubby.Event
+}
+
+// reconnectOption returns description of --no-reconnect option shared
+// by all long-running commands.
+func reconnectOption() *opt.Desc {
+	return &opt.Desc{"", "no-reconnect", opt.ArgNone, "",
+		"exit when connection to the server is lost"}
+}
+
+// openEventStream starts receiving events from the server specified by
+// settings. ch is the command connection used by the caller, it is
+// replaced after reconnection and should be obtained with Chubby method
+// afterwards.
+func openEventStream(settings *Settings, ch *chubby.Chubby,
+	opts opt.Options) (*eventStream, error) {
+
+	s := &eventStream{
+		out:       make(chan Event),
+		done:      make(chan bool),
+		reconnect: !opts.Has("no-reconnect"),
+		settings:  settings,
+		ch:        ch,
+		orig:      ch,
+	}
+	s.C = s.out
+	if err := s.subscribe(); err != nil {
+		return nil, err
+	}
+	go s.run()
+
+	return s, nil
+}
+
+// Chubby returns current command connection.
+func (s *eventStream) Chubby() *chubby.Chubby {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ch
+}
+
+// Close stops receiving events and closes events connection and
+// command connection if it was replaced.
+func (s *eventStream) Close() {
+	close(s.done)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.evch != nil {
+		s.evch.Close()
+	}
+	if s.ch != s.orig {
+		s.ch.Close()
+	}
+}
+
+// Redial replaces command connection with a new one, for example after
+// it was closed because of a timeout.
+func (s *eventStream) Redial() error {
+	ch, err := dialTo(s.settings)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	old := s.ch
+	s.ch = ch
+	s.mu.Unlock()
+	if old != s.orig {
+		old.Close()
+	}
+
+	return nil
+}
+
+// Status returns current player status over the command connection.
+// Event can arrive right before the server closes connections, when it
+// is restarted for example, so failed command connection is redialed
+// once. If it fails again false is returned, callers should treat it as
+// disconnected event and wait for the stream to reconnect.
+func (s *eventStream) Status() (chubby.Status, bool, error) {
+	st, err := s.Chubby().Status()
+	if err != nil && isConnError(err) && s.Redial() == nil {
+		st, err = s.Chubby().Status()
+	}
+	if err != nil && isConnError(err) {
+		return st, false, nil
+	} else if err != nil {
+		return st, false, err
+	}
+
+	return st, true, nil
+}
+
+func (s *eventStream) subscribe() error {
+	evch, err := dialTo(s.settings)
+	if err != nil {
+		return err
+	}
+	events, err := evch.Events(true)
+	if err != nil {
+		evch.Close()
+		return err
+	}
+	s.mu.Lock()
+	s.evch = evch
+	s.mu.Unlock()
+	s.events = events
+
+	return nil
+}
+
+func (s *eventStream) run() {
+	defer close(s.out)
+	for {
+		for e := <-s.events; e != nil; e = <-s.events {
+			if !s.send(e) {
+				return
+			}
+		}
+		s.mu.Lock()
+		s.evch.Close()
+		s.evch = nil
+		s.mu.Unlock()
+		if !s.reconnect {
+			return
+		}
+		if !s.send(&clientEvent{EventDisconnected, ""}) {
+			return
+		}
+
+		data, ok := s.reconnectLoop()
+		if !ok {
+			return
+		}
+		if !s.send(&clientEvent{EventReconnected, data}) {
+			return
+		}
+	}
+}
+
+// reconnectLoop connects to the server until succeeded or the stream is
+// closed. Current player status serialized as event data is returned.
+func (s *eventStream) reconnectLoop() (string, bool) {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-time.After(reconnectDelay(attempt)):
+		case <-s.done:
+			return "", false
+		}
+
+		ch, err := dialTo(s.settings)
+		if err != nil {
+			continue
+		}
+		st, err := ch.Status()
+		if err == nil {
+			err = s.subscribe()
+		}
+		if err != nil {
+			ch.Close()
+			continue
+		}
+		s.mu.Lock()
+		old := s.ch
+		s.ch = ch
+		s.mu.Unlock()
+		if old != nil && old != s.orig {
+			old.Close()
+		}
+
+		return serializeStatus(st), true
+	}
+}
+
+func (s *eventStream) send(e Event) bool {
+	select {
+	case s.out <- e:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// reconnectDelay returns delay before the reconnection attempt.
+// Delay grows exponentially with random jitter up to a half of it.
+func reconnectDelay(attempt int) time.Duration {
+	d := reconnectMaxDelay
+	if attempt < 16 {
+		d = min(reconnectMinDelay<<attempt, reconnectMaxDelay)
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// serializeStatus serializes player status as `key: value` lines
+// decodable with decodeEventData.
+func serializeStatus(s chubby.Status) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "state: %s\nvolume: %d\n", s.State, s.Volume)
+	if s.State != chubby.StateStopped {
+		fmt.Fprintf(&b, "playlist: %s\nplaylist pos: %d\n",
+			s.Playlist.Name, s.PlaylistPos+1)
+		fmt.Fprintf(&b, "path: %s\nartist: %s\nalbum: %s\ntitle: %s\n",
+			s.Track.Path, s.Track.Artist, s.Track.Album,
+			s.Track.Title)
+		fmt.Fprintf(&b, "track pos: %s\n", s.TrackPos)
+	}
+
+	return b.String()
+}
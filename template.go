@@ -0,0 +1,147 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/vchimishuk/chubby/time"
+	"github.com/vchimishuk/opt"
+)
+
+var templateFuncs = template.FuncMap{
+	"duration": templateDuration,
+	"json":     templateJSON,
+	"lower":    strings.ToLower,
+	"lpad":     templateLpad,
+	"pad":      templatePad,
+	"trunc":    templateTrunc,
+	"upper":    strings.ToUpper,
+}
+
+// templateOption returns description of --template option shared
+// by all commands which support template output.
+func templateOption() *opt.Desc {
+	return &opt.Desc{"", "template", opt.ArgString, "TEMPLATE",
+		"render output with Go template, @FILE reads it from file"}
+}
+
+// parseTemplate compiles Go template given with --template option.
+// Template started with `@` is read from the file. Nil template is
+// returned if the option is not given.
+func parseTemplate(opts opt.Options) (*template.Template, error) {
+	if !opts.Has("template") {
+		return nil, nil
+	}
+	s := opts.StringOr("template", "")
+	if strings.HasPrefix(s, "@") {
+		b, err := os.ReadFile(s[1:])
+		if err != nil {
+			return nil, err
+		}
+		s = string(b)
+	}
+
+	return template.New("template").Funcs(templateFuncs).Parse(s)
+}
+
+// renderTemplate renders template for the data value.
+func renderTemplate(t *template.Template, data interface{}) (string, error) {
+	var b strings.Builder
+	err := t.Execute(&b, data)
+	if err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// printTemplate renders template for the data value and writes it to w.
+// New line is added if rendered text does not end with one.
+func printTemplate(w io.Writer, t *template.Template,
+	data interface{}) error {
+
+	s, err := renderTemplate(t, data)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	fmt.Fprint(w, s)
+
+	return nil
+}
+
+// templateDuration formats chubby time or number of seconds
+// as [h:]mm:ss string.
+func templateDuration(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return formatSeconds(seconds(t)), nil
+	case int:
+		return formatSeconds(t), nil
+	default:
+		return "", fmt.Errorf("duration: unsupported type %T", v)
+	}
+}
+
+func templateJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// templatePad pads value with spaces on the right up to n characters.
+func templatePad(n int, v interface{}) string {
+	s := fmt.Sprint(v)
+	if l := utf8.RuneCountInString(s); l < n {
+		s += strings.Repeat(" ", n-l)
+	}
+
+	return s
+}
+
+// templateLpad pads value with spaces on the left up to n characters.
+func templateLpad(n int, v interface{}) string {
+	s := fmt.Sprint(v)
+	if l := utf8.RuneCountInString(s); l < n {
+		s = strings.Repeat(" ", n-l) + s
+	}
+
+	return s
+}
+
+// templateTrunc truncates value to n characters ending it with ellipsis.
+func templateTrunc(n int, v interface{}) string {
+	s := fmt.Sprint(v)
+	if n > 0 && utf8.RuneCountInString(s) > n {
+		s = string([]rune(s)[:n-1]) + "…"
+	}
+
+	return s
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Signal sent on terminal window size change.
+var winchSignal os.Signal = syscall.SIGWINCH
+
+type termState struct {
+	termios syscall.Termios
+}
+
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd),
+		req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+func isTerminal(fd int) bool {
+	var t syscall.Termios
+
+	return ioctl(fd, ioctlGetTermios, unsafe.Pointer(&t)) == nil
+}
+
+// makeRaw puts terminal into raw mode and returns its previous state
+// which can be restored later with restoreTerm.
+func makeRaw(fd int) (*termState, error) {
+	var st termState
+	err := ioctl(fd, ioctlGetTermios, unsafe.Pointer(&st.termios))
+	if err != nil {
+		return nil, err
+	}
+
+	t := st.termios
+	t.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK |
+		syscall.ISTRIP | syscall.IXON
+	t.Cflag |= syscall.CS8
+	t.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN |
+		syscall.ISIG
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+	err = ioctl(fd, ioctlSetTermios, unsafe.Pointer(&t))
+	if err != nil {
+		return nil, err
+	}
+
+	return &st, nil
+}
+
+func restoreTerm(fd int, st *termState) error {
+	return ioctl(fd, ioctlSetTermios, unsafe.Pointer(&st.termios))
+}
+
+// termSize returns terminal width and height.
+func termSize(fd int) (int, int, error) {
+	var ws struct {
+		Row    uint16
+		Col    uint16
+		Xpixel uint16
+		Ypixel uint16
+	}
+	err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(ws.Col), int(ws.Row), nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vchimishuk/chubby/time"
+)
+
+// seconds converts chubby time value into number of seconds.
+func seconds(t time.Time) int {
+	n := 0
+	for _, p := range strings.Split(t.String(), ":") {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return 0
+		}
+		n = n*60 + v
+	}
+
+	return n
+}
+
+// formatSeconds formats number of seconds as [h:]mm:ss string.
+func formatSeconds(s int) string {
+	if s < 0 {
+		s = 0
+	}
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
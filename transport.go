@@ -0,0 +1,352 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// serverAddr is a parsed server address.
+type serverAddr struct {
+	// Network is either tcp or unix.
+	Network string
+	Host    string
+	Port    int
+	// Socket file path for unix network.
+	Path string
+}
+
+// parseServerAddr parses server address given as a host name, host:port
+// pair, IPv6 literal optionally enclosed in brackets, chub://host:port
+// or unix:///path URL. Port is used if address does not contain one.
+func parseServerAddr(host string, port int) (serverAddr, error) {
+	if strings.Contains(host, "://") {
+		u, err := url.Parse(host)
+		if err != nil {
+			return serverAddr{}, errors.New("invalid URL")
+		}
+		switch u.Scheme {
+		case "unix":
+			if u.Host != "" || !path.IsAbs(u.Path) {
+				return serverAddr{},
+					errors.New("absolute socket path expected")
+			}
+			return serverAddr{Network: "unix", Path: u.Path}, nil
+		case "chub":
+			if u.Path != "" && u.Path != "/" {
+				return serverAddr{}, errors.New("unexpected path")
+			}
+			host = u.Host
+		default:
+			return serverAddr{},
+				fmt.Errorf("unsupported scheme: %s", u.Scheme)
+		}
+	}
+
+	// Host with several colons and without brackets is a bare IPv6
+	// literal without port.
+	h := host
+	p := ""
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		h = host[1 : len(host)-1]
+	} else if strings.HasPrefix(host, "[") || strings.Count(host, ":") == 1 {
+		var err error
+		h, p, err = net.SplitHostPort(host)
+		if err != nil {
+			return serverAddr{}, errors.New("invalid host:port pair")
+		}
+	}
+	if h == "" {
+		return serverAddr{}, errors.New("host name expected")
+	}
+	if p != "" {
+		var err error
+		port, err = strconv.Atoi(p)
+		if err != nil {
+			return serverAddr{}, fmt.Errorf("invalid port number: %s", p)
+		}
+	}
+	if port <= 0 || port > 65535 {
+		return serverAddr{}, fmt.Errorf("invalid port number: %d", port)
+	}
+
+	return serverAddr{Network: "tcp", Host: h, Port: port}, nil
+}
+
+// String returns address in the form accepted by parseServerAddr.
+func (a serverAddr) String() string {
+	if a.Network == "unix" {
+		return "unix://" + a.Path
+	}
+
+	return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+}
+
+// connector is a client which can connect to a TCP server, like
+// chubby.Chubby.
+type connector interface {
+	Connect(host string, port int) error
+}
+
+// connectAddr connects client to the server address directly or through
+// the proxy specified by settings.
+func connectAddr(c connector, s *Settings) error {
+	a := s.ServerAddr()
+	proxy := s.ProxyCommand.Value != "" || s.Socks5.Value != ""
+	if a.Network == "unix" && proxy {
+		return errors.New("proxy can not be used with unix socket")
+	}
+
+	switch {
+	case s.ProxyCommand.Value != "":
+		return connectRelay(c, func() (io.ReadWriteCloser, error) {
+			return dialProxyCommand(s.ProxyCommand.Value, a)
+		})
+	case s.Socks5.Value != "":
+		return connectRelay(c, func() (io.ReadWriteCloser, error) {
+			return dialSocks5(s.Socks5Addr(), a)
+		})
+	case a.Network == "unix":
+		return connectRelay(c, func() (io.ReadWriteCloser, error) {
+			return net.Dial("unix", a.Path)
+		})
+	case strings.Contains(a.Host, ":"):
+		// Client does not enclose IPv6 literals in brackets when
+		// joins host and port, so such addresses are dialed by
+		// ourselves.
+		return connectRelay(c, func() (io.ReadWriteCloser, error) {
+			return net.Dial("tcp", a.String())
+		})
+	default:
+		return c.Connect(a.Host, a.Port)
+	}
+}
+
+// connectRelay connects client to the server over the connection
+// returned by dial. Client supports TCP connections only, so it is
+// connected to a temporary loopback listener which relays accepted
+// connection to the dialed one and is closed right after that.
+func connectRelay(c connector, dial func() (io.ReadWriteCloser, error)) error {
+	if !relaySupported {
+		return errors.New("proxies, unix sockets and IPv6 addresses " +
+			"are not supported on this platform")
+	}
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	go acceptRelay(l, conn)
+
+	err = c.Connect("127.0.0.1", l.Addr().(*net.TCPAddr).Port)
+	// Otherwise listener is closed by acceptRelay, connection can
+	// still be waiting in the accept queue at this point.
+	if err != nil {
+		l.Close()
+	}
+
+	return err
+}
+
+// acceptRelay relays the first connection to the listener made by the
+// current process to conn. Loopback listener is reachable by other local
+// users, so connections of other processes are closed, otherwise they
+// would bypass server access control, like unix socket permissions.
+func acceptRelay(l net.Listener, conn io.ReadWriteCloser) {
+	for {
+		lc, err := l.Accept()
+		if err != nil {
+			conn.Close()
+			return
+		}
+		if ownConn(lc) {
+			l.Close()
+			relay(lc, conn)
+			return
+		}
+		lc.Close()
+	}
+}
+
+// relay copies data between connections in both directions until one
+// of them is closed and closes both connections afterwards.
+func relay(a io.ReadWriteCloser, b io.ReadWriteCloser) {
+	var once sync.Once
+	closeBoth := func() {
+		a.Close()
+		b.Close()
+	}
+	go func() {
+		io.Copy(a, b)
+		once.Do(closeBoth)
+	}()
+	io.Copy(b, a)
+	once.Do(closeBoth)
+}
+
+// proxyConn is a connection to the server tunneled over standard input
+// and output of the proxy command.
+type proxyConn struct {
+	cmd *exec.Cmd
+	io.ReadCloser
+	io.WriteCloser
+}
+
+// dialProxyCommand starts proxy command with %h and %p sequences
+// replaced with server host and port, and %% with %.
+func dialProxyCommand(command string, a serverAddr) (io.ReadWriteCloser, error) {
+	r := strings.NewReplacer("%h", a.Host, "%p", strconv.Itoa(a.Port),
+		"%%", "%")
+	cmd := exec.Command("/bin/sh", "-c", "exec "+r.Replace(command))
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("proxy command: %w", err)
+	}
+
+	return &proxyConn{cmd, out, in}, nil
+}
+
+// Close closes command standard input and kills it if it is still
+// running.
+func (c *proxyConn) Close() error {
+	c.WriteCloser.Close()
+	c.cmd.Process.Kill()
+	c.cmd.Wait()
+
+	return nil
+}
+
+// SOCKS5 reply codes descriptions.
+var socks5Errors = map[byte]string{
+	1: "general SOCKS server failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// dialSocks5 connects to the server through SOCKS5 proxy. Server host
+// name is resolved by the proxy.
+func dialSocks5(proxy serverAddr, a serverAddr) (io.ReadWriteCloser, error) {
+	conn, err := net.Dial("tcp", proxy.String())
+	if err != nil {
+		return nil, err
+	}
+	err = socks5Connect(conn, a)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("socks5: %w", err)
+	}
+
+	return conn, nil
+}
+
+// socks5Connect performs SOCKS5 handshake without authentication and
+// sends CONNECT request.
+func socks5Connect(conn net.Conn, a serverAddr) error {
+	_, err := conn.Write([]byte{5, 1, 0})
+	if err != nil {
+		return err
+	}
+	resp := make([]byte, 2)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return err
+	}
+	if resp[0] != 5 {
+		return errors.New("invalid server response")
+	}
+	if resp[1] != 0 {
+		return errors.New("no acceptable authentication method")
+	}
+
+	req := []byte{5, 1, 0}
+	ip := net.ParseIP(a.Host)
+	if ip4 := ip.To4(); ip4 != nil {
+		req = append(append(req, 1), ip4...)
+	} else if ip != nil {
+		req = append(append(req, 4), ip.To16()...)
+	} else if len(a.Host) <= 255 {
+		req = append(append(req, 3, byte(len(a.Host))), a.Host...)
+	} else {
+		return errors.New("host name too long")
+	}
+	req = append(req, byte(a.Port>>8), byte(a.Port))
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	// Reply contains version, reply code, reserved byte, bound address
+	// type, address and port.
+	hdr := make([]byte, 4)
+	if _, err := io.ReadFull(conn, hdr); err != nil {
+		return err
+	}
+	if hdr[0] != 5 {
+		return errors.New("invalid server response")
+	}
+	if hdr[1] != 0 {
+		msg, ok := socks5Errors[hdr[1]]
+		if !ok {
+			msg = fmt.Sprintf("unknown error %d", hdr[1])
+		}
+		return errors.New(msg)
+	}
+	var n int
+	switch hdr[3] {
+	case 1:
+		n = net.IPv4len
+	case 4:
+		n = net.IPv6len
+	case 3:
+		l := make([]byte, 1)
+		if _, err := io.ReadFull(conn, l); err != nil {
+			return err
+		}
+		n = int(l[0])
+	default:
+		return errors.New("invalid server response")
+	}
+	_, err = io.ReadFull(conn, make([]byte, n+2))
+
+	return err
+}
@@ -0,0 +1,430 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseServerAddr(t *testing.T) {
+	tests := []struct {
+		host string
+		addr serverAddr
+		str  string
+	}{
+		{"localhost", serverAddr{"tcp", "localhost", 5115, ""},
+			"localhost:5115"},
+		{"player.lan:6000", serverAddr{"tcp", "player.lan", 6000, ""},
+			"player.lan:6000"},
+		{"10.0.0.2", serverAddr{"tcp", "10.0.0.2", 5115, ""},
+			"10.0.0.2:5115"},
+		{"chub://player.lan:6000", serverAddr{"tcp", "player.lan", 6000, ""},
+			"player.lan:6000"},
+		{"chub://player.lan/", serverAddr{"tcp", "player.lan", 5115, ""},
+			"player.lan:5115"},
+		{"chub://[::1]:6000", serverAddr{"tcp", "::1", 6000, ""},
+			"[::1]:6000"},
+		{"unix:///run/chub.sock", serverAddr{"unix", "", 0, "/run/chub.sock"},
+			"unix:///run/chub.sock"},
+		{"[::1]:6000", serverAddr{"tcp", "::1", 6000, ""}, "[::1]:6000"},
+		{"[::1]", serverAddr{"tcp", "::1", 5115, ""}, "[::1]:5115"},
+		{"::1", serverAddr{"tcp", "::1", 5115, ""}, "[::1]:5115"},
+		{"fe80::1:2", serverAddr{"tcp", "fe80::1:2", 5115, ""},
+			"[fe80::1:2]:5115"},
+	}
+
+	for _, test := range tests {
+		a, err := parseServerAddr(test.host, 5115)
+		if err != nil {
+			t.Errorf("%s: %s", test.host, err)
+			continue
+		}
+		if a != test.addr {
+			t.Errorf("%s: expected %+v, got %+v", test.host, test.addr, a)
+		}
+		if a.String() != test.str {
+			t.Errorf("%s: expected %s, got %s", test.host, test.str, a)
+		}
+	}
+}
+
+func TestParseServerAddrErrors(t *testing.T) {
+	tests := []struct {
+		host string
+		err  string
+	}{
+		{"unix://run/chub.sock", "absolute socket path expected"},
+		{"ftp://player.lan", "unsupported scheme: ftp"},
+		{"chub://player.lan/path", "unexpected path"},
+		{"chub://", "host name expected"},
+		{":6000", "host name expected"},
+		{"[::1", "invalid host:port pair"},
+		{"player.lan:abc", "invalid port number: abc"},
+		{"player.lan:70000", "invalid port number: 70000"},
+	}
+
+	for _, test := range tests {
+		_, err := parseServerAddr(test.host, 5115)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: expected %q, got %v", test.host, test.err, err)
+		}
+	}
+}
+
+func TestConnectAddr(t *testing.T) {
+	l := listen(t, "tcp", "127.0.0.1:0")
+	port := l.Addr().(*net.TCPAddr).Port
+	testConnectAddr(t, l, fmt.Sprintf("127.0.0.1:%d", port), 0)
+	testConnectAddr(t, l, fmt.Sprintf("chub://127.0.0.1:%d", port), 0)
+	testConnectAddr(t, l, "127.0.0.1", port)
+}
+
+func TestConnectAddrUnix(t *testing.T) {
+	skipRelay(t)
+	p := filepath.Join(t.TempDir(), "chub.sock")
+	l := listen(t, "unix", p)
+	for i := 0; i < 100; i++ {
+		testConnectAddr(t, l, "unix://"+p, 0)
+	}
+}
+
+func TestConnectAddrIPv6(t *testing.T) {
+	skipRelay(t)
+	l, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 is not available:", err)
+	}
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+	for i := 0; i < 100; i++ {
+		testConnectAddr(t, l, fmt.Sprintf("[::1]:%d", port), 0)
+	}
+	testConnectAddr(t, l, fmt.Sprintf("chub://[::1]:%d", port), 0)
+	testConnectAddr(t, l, "::1", port)
+}
+
+func TestConnectRelayUnsupported(t *testing.T) {
+	if relaySupported {
+		t.Skip("relay is supported")
+	}
+	s := &Settings{Host: Setting{"unix:///run/chub.sock", "test"}}
+	if err := connectAddr(&testConnector{}, s); err == nil {
+		t.Errorf("relay is not refused")
+	}
+}
+
+func TestAcceptRelay(t *testing.T) {
+	skipRelay(t)
+	l := listen(t, "tcp", "127.0.0.1:0")
+	client, server := net.Pipe()
+	defer server.Close()
+	go acceptRelay(l, client)
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+	server.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := c.Write([]byte("ping\n")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(server, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping\n" {
+		t.Errorf("unexpected request: %q", buf)
+	}
+	if _, err := server.Write([]byte("pong\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(c, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "pong\n" {
+		t.Errorf("unexpected response: %q", buf)
+	}
+
+	// Listener is closed after the first connection.
+	if _, err := net.Dial("tcp", l.Addr().String()); err == nil {
+		t.Errorf("relay listener is still open")
+	}
+}
+
+func listen(t *testing.T, network string, addr string) net.Listener {
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	return l
+}
+
+// testConnector connects to the server like chubby.Chubby does and
+// keeps the connection.
+type testConnector struct {
+	conn net.Conn
+}
+
+func (c *testConnector) Connect(host string, port int) error {
+	conn, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	c.conn = conn
+
+	return err
+}
+
+// skipRelay skips test which needs connection relay if it is not
+// supported on this system.
+func skipRelay(t *testing.T) {
+	if !relaySupported {
+		t.Skip("relay is not supported on this system")
+	}
+}
+
+// testConnectAddr connects to the server which echoes received data
+// and checks that data is delivered both ways.
+func testConnectAddr(t *testing.T, l net.Listener, host string, port int) {
+	s := &Settings{
+		Host: Setting{host, "test"},
+		Port: Setting{strconv.Itoa(port), "test"},
+	}
+	if port == 0 {
+		s.Port = Setting{strconv.Itoa(DefaultPort), "test"}
+	}
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			io.Copy(conn, conn)
+			conn.Close()
+		}
+	}()
+	testRoundTrip(t, host, s)
+}
+
+// testRoundTrip connects to the server with settings and checks that
+// sent data is echoed back.
+func testRoundTrip(t *testing.T, name string, s *Settings) {
+	c := &testConnector{}
+	if err := connectAddr(c, s); err != nil {
+		t.Errorf("%s: %s", name, err)
+		return
+	}
+	defer c.conn.Close()
+	c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := c.conn.Write([]byte("ping\n")); err != nil {
+		t.Errorf("%s: %s", name, err)
+		return
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(c.conn, buf); err != nil {
+		t.Errorf("%s: %s", name, err)
+	} else if string(buf) != "ping\n" {
+		t.Errorf("%s: unexpected response: %q", name, buf)
+	}
+}
+
+func TestDialProxyCommand(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("shell is not available")
+	}
+	a := serverAddr{Network: "tcp", Host: "player.lan", Port: 5115}
+
+	conn, err := dialProxyCommand(`printf '%%s %%s %%%%\n' %h %p`, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(conn)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "player.lan 5115 %\n" {
+		t.Errorf("unexpected command output: %q", out)
+	}
+
+	conn, err = dialProxyCommand("cat", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping\n")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping\n" {
+		t.Errorf("unexpected response: %q", buf)
+	}
+}
+
+func TestDialSocks5(t *testing.T) {
+	tests := []struct {
+		addr serverAddr
+		req  []byte
+	}{
+		{serverAddr{"tcp", "10.0.0.2", 5115, ""},
+			[]byte{5, 1, 0, 1, 10, 0, 0, 2, 0x13, 0xfb}},
+		{serverAddr{"tcp", "::1", 6000, ""},
+			[]byte{5, 1, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				0, 0, 0, 1, 0x17, 0x70}},
+		{serverAddr{"tcp", "player.lan", 5115, ""},
+			append(append([]byte{5, 1, 0, 3, 10}, "player.lan"...),
+				0x13, 0xfb)},
+	}
+
+	for _, test := range tests {
+		l := listen(t, "tcp", "127.0.0.1:0")
+		reqs := make(chan []byte, 1)
+		go socks5Server(l, 0, reqs)
+		proxy, _ := parseServerAddr(l.Addr().String(), 0)
+
+		conn, err := dialSocks5(proxy, test.addr)
+		if err != nil {
+			t.Errorf("%s: %s", test.addr, err)
+			continue
+		}
+		if req := <-reqs; !bytes.Equal(req, test.req) {
+			t.Errorf("%s: expected request %v, got %v", test.addr,
+				test.req, req)
+		}
+		conn.Write([]byte("ping\n"))
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(conn, buf); err != nil ||
+			string(buf) != "ping\n" {
+			t.Errorf("%s: unexpected response: %q, %v", test.addr,
+				buf, err)
+		}
+		conn.Close()
+	}
+}
+
+// TestConnectAddrProxy checks that data is relayed to the proxy command
+// and SOCKS5 proxy connections made by connectAddr.
+func TestConnectAddrProxy(t *testing.T) {
+	skipRelay(t)
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("shell is not available")
+	}
+	s := &Settings{
+		Host:         Setting{"player.lan", "test"},
+		Port:         Setting{strconv.Itoa(DefaultPort), "test"},
+		ProxyCommand: Setting{"cat", "test"},
+	}
+	for i := 0; i < 20; i++ {
+		testRoundTrip(t, "proxy command", s)
+	}
+
+	s.ProxyCommand = Setting{}
+	for i := 0; i < 20; i++ {
+		l := listen(t, "tcp", "127.0.0.1:0")
+		reqs := make(chan []byte, 1)
+		go socks5Server(l, 0, reqs)
+		s.Socks5 = Setting{l.Addr().String(), "test"}
+		testRoundTrip(t, "socks5", s)
+		expected := append(append([]byte{5, 1, 0, 3, 10}, "player.lan"...),
+			0x13, 0xfb)
+		if req := <-reqs; !bytes.Equal(req, expected) {
+			t.Errorf("expected request %v, got %v", expected, req)
+		}
+	}
+}
+
+func TestDialSocks5Errors(t *testing.T) {
+	a := serverAddr{"tcp", "player.lan", 5115, ""}
+	for code, msg := range map[byte]string{
+		5:    "socks5: connection refused",
+		0x20: "socks5: unknown error 32",
+		0xff: "socks5: no acceptable authentication method",
+	} {
+		l := listen(t, "tcp", "127.0.0.1:0")
+		go socks5Server(l, code, make(chan []byte, 1))
+		proxy, _ := parseServerAddr(l.Addr().String(), 0)
+
+		_, err := dialSocks5(proxy, a)
+		if err == nil || err.Error() != msg {
+			t.Errorf("expected %q, got %v", msg, err)
+		}
+	}
+}
+
+// socks5Server accepts a single SOCKS5 connection, sends received
+// CONNECT request to reqs and replies with the given code. 0xff code
+// rejects all authentication methods. After successful reply received
+// data is sent back.
+func socks5Server(l net.Listener, code byte, reqs chan<- []byte) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	hello := make([]byte, 3)
+	if _, err := io.ReadFull(conn, hello); err != nil {
+		return
+	}
+	if code == 0xff {
+		conn.Write([]byte{5, 0xff})
+		return
+	}
+	conn.Write([]byte{5, 0})
+
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+	n := 0
+	switch req[3] {
+	case 1:
+		n = net.IPv4len
+	case 4:
+		n = net.IPv6len
+	case 3:
+		l := make([]byte, 1)
+		if _, err := io.ReadFull(conn, l); err != nil {
+			return
+		}
+		req = append(req, l[0])
+		n = int(l[0])
+	}
+	rest := make([]byte, n+2)
+	if _, err := io.ReadFull(conn, rest); err != nil {
+		return
+	}
+	reqs <- append(req, rest...)
+
+	conn.Write([]byte{5, code, 0, 3, 4, 'h', 'o', 's', 't', 0, 1})
+	if code == 0 {
+		io.Copy(conn, conn)
+	}
+}
@@ -0,0 +1,476 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+// Special keys codes returned by readKey.
+const (
+	keyUp rune = -1 - iota
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyPgUp
+	keyPgDown
+)
+
+type TuiCommand struct {
+}
+
+func NewTuiCommand() TuiCommand {
+	return TuiCommand{}
+}
+
+func (c TuiCommand) Name() string {
+	return "tui"
+}
+
+func (c TuiCommand) Options() []*opt.Desc {
+	return []*opt.Desc{reconnectOption()}
+}
+
+func (c TuiCommand) Args() (int, int) {
+	return 0, 0
+}
+
+func (c TuiCommand) LongRunning(opts opt.Options) bool {
+	return true
+}
+
+func (c TuiCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
+	fd := int(os.Stdin.Fd())
+	if !isTerminal(fd) {
+		return errors.New("terminal required")
+	}
+
+	stream, err := openEventStream(env.Settings, ch, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	st, err := makeRaw(fd)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(os.Stdout)
+	// Switch to alternate screen and hide cursor.
+	out.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		out.WriteString("\x1b[?25h\x1b[?1049l")
+		out.Flush()
+		restoreTerm(fd, st)
+	}()
+
+	t := &tui{env: env, ch: ch, out: out, fd: fd, cache: map[string][]vfsEntry{}}
+	t.chdir(workDir)
+	t.refreshStatus()
+
+	return t.run(stream)
+}
+
+type tui struct {
+	env    *Env
+	ch     *chubby.Chubby
+	out    *bufio.Writer
+	fd     int
+	width  int
+	height int
+	// Current directory and its contents.
+	dir     string
+	entries []vfsEntry
+	cursor  int
+	offset  int
+	// Directory listings cache used for preview pane.
+	cache  map[string][]vfsEntry
+	status chubby.Status
+	// Current track position and length in seconds.
+	pos    int
+	length int
+	msg    string
+}
+
+// run executes main UI loop. Every event received from the stream
+// signals player state change.
+func (t *tui) run(stream *eventStream) error {
+	events := stream.C
+	keys := make(chan rune)
+	keyErrs := make(chan error, 1)
+	go func() {
+		in := bufio.NewReader(os.Stdin)
+		for {
+			k, err := readKey(in)
+			if err != nil {
+				keyErrs <- err
+				return
+			}
+			keys <- k
+		}
+	}()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	if winchSignal != nil {
+		signal.Notify(sigs, winchSignal)
+	}
+	defer signal.Stop(sigs)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		t.draw()
+
+		select {
+		case k := <-keys:
+			t.msg = ""
+			if k == 'q' || k == 3 {
+				return nil
+			}
+			t.handleKey(k)
+		case err := <-keyErrs:
+			return err
+		case e := <-events:
+			switch {
+			case e == nil:
+				t.msg = errConnLost.Error()
+				events = nil
+			case e.Event() == EventDisconnected:
+				t.msg = "disconnected, reconnecting..."
+			case e.Event() == EventReconnected:
+				t.msg = ""
+				t.ch = stream.Chubby()
+				t.refreshStatus()
+			default:
+				t.refreshStatus()
+			}
+		case <-ticker.C:
+			if t.status.State == chubby.StatePlaying &&
+				t.pos < t.length {
+				t.pos++
+			}
+		case sig := <-sigs:
+			if sig != winchSignal {
+				return nil
+			}
+		}
+	}
+}
+
+func (t *tui) handleKey(k rune) {
+	var err error
+
+	switch k {
+	case keyUp, 'k':
+		t.move(-1)
+	case keyDown, 'j':
+		t.move(1)
+	case keyPgUp:
+		t.move(-t.listHeight())
+	case keyPgDown:
+		t.move(t.listHeight())
+	case keyHome, 'g':
+		t.move(-len(t.entries))
+	case keyEnd, 'G':
+		t.move(len(t.entries))
+	case keyRight, 'l', '\r', '\n':
+		if e, ok := t.selected(); ok {
+			if e.Dir {
+				t.chdir(e.Path)
+			} else {
+				err = t.ch.Play(e.Path)
+			}
+		}
+	case keyLeft, 'h', 127, 8:
+		if t.dir != "/" {
+			prev := t.dir
+			t.chdir(path.Dir(t.dir))
+			for i, e := range t.entries {
+				if e.Path == prev {
+					t.cursor = i
+				}
+			}
+		}
+	case 'p':
+		if e, ok := t.selected(); ok {
+			err = t.ch.Play(e.Path)
+		}
+	case ' ':
+		err = runCommand(t.env, t.ch, NewPauseCommand())
+	case 's':
+		err = runCommand(t.env, t.ch, NewStopCommand())
+	case 'n':
+		err = runCommand(t.env, t.ch, NewNextCommand())
+	case 'b':
+		err = runCommand(t.env, t.ch, NewPrevCommand())
+	case '[':
+		err = runCommand(t.env, t.ch, NewSeekCommand(), "-10")
+	case ']':
+		err = runCommand(t.env, t.ch, NewSeekCommand(), "+10")
+	case '-':
+		err = runCommand(t.env, t.ch, NewVolumeCommand(), "-5")
+	case '+', '=':
+		err = runCommand(t.env, t.ch, NewVolumeCommand(), "+5")
+	}
+	if err != nil {
+		t.msg = err.Error()
+	}
+	t.refreshStatus()
+}
+
+func (t *tui) selected() (vfsEntry, bool) {
+	if t.cursor < len(t.entries) {
+		return t.entries[t.cursor], true
+	}
+
+	return vfsEntry{}, false
+}
+
+func (t *tui) move(n int) {
+	t.cursor = max(0, min(t.cursor+n, len(t.entries)-1))
+}
+
+func (t *tui) chdir(dir string) {
+	entries, err := t.list(dir)
+	if err != nil {
+		t.msg = err.Error()
+		return
+	}
+	t.dir = dir
+	t.entries = entries
+	t.cursor = 0
+	t.offset = 0
+}
+
+func (t *tui) list(dir string) ([]vfsEntry, error) {
+	if entries, ok := t.cache[dir]; ok {
+		return entries, nil
+	}
+	entries, err := listDir(t.ch, dir)
+	if err != nil {
+		return nil, err
+	}
+	t.cache[dir] = entries
+
+	return entries, nil
+}
+
+func (t *tui) refreshStatus() {
+	s, err := t.ch.Status()
+	if err != nil {
+		t.msg = err.Error()
+		return
+	}
+	t.status = s
+	t.pos = seconds(s.TrackPos)
+	t.length = seconds(s.Track.Length)
+}
+
+// listHeight returns number of rows available for browser panes.
+// Besides panes screen contains header, separator, two now playing
+// lines and a help line.
+func (t *tui) listHeight() int {
+	return max(1, t.height-5)
+}
+
+func (t *tui) draw() {
+	w, h, err := termSize(t.fd)
+	if err != nil {
+		w, h = 80, 24
+	}
+	t.width, t.height = w, h
+	if w < 20 || h < 8 {
+		t.out.WriteString("\x1b[H\x1b[2J")
+		t.out.WriteString(fit("terminal is too small", w))
+		t.out.Flush()
+		return
+	}
+
+	lh := t.listHeight()
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	} else if t.cursor >= t.offset+lh {
+		t.offset = t.cursor - lh + 1
+	}
+
+	lw := w / 2
+	rw := w - lw - 1
+	preview := t.preview()
+
+	t.line(1, "\x1b[7m"+fit(" "+t.dir, w)+"\x1b[0m")
+	for i := 0; i < lh; i++ {
+		var left, right string
+		if j := t.offset + i; j < len(t.entries) {
+			e := t.entries[j]
+			left = " " + e.Name()
+			if e.Dir {
+				left += "/"
+			}
+			left = fit(left, lw)
+			if j == t.cursor {
+				left = "\x1b[7m" + left + "\x1b[0m"
+			}
+		} else {
+			left = fit("", lw)
+		}
+		if i < len(preview) {
+			right = preview[i]
+		}
+		t.line(i+2, left+"|"+fit(" "+right, rw))
+	}
+	t.line(lh+2, strings.Repeat("-", w))
+	t.line(lh+3, fit(" "+t.nowPlaying(), w))
+	t.line(lh+4, t.progress(w))
+	help := "enter:open  p:play  space:pause  s:stop  n/b:next/prev  " +
+		"[/]:seek  -/+:volume  q:quit"
+	if t.msg != "" {
+		help = t.msg
+	}
+	t.line(lh+5, fit(" "+help, w))
+	t.out.Flush()
+}
+
+func (t *tui) line(row int, s string) {
+	fmt.Fprintf(t.out, "\x1b[%d;1H%s\x1b[K", row, s)
+}
+
+func (t *tui) preview() []string {
+	e, ok := t.selected()
+	if !ok {
+		return nil
+	}
+	if !e.Dir {
+		return []string{
+			"Artist: " + e.Track.Artist,
+			"Album:  " + e.Track.Album,
+			"Title:  " + e.Track.Title,
+			fmt.Sprintf("Year:   %d", e.Track.Year),
+			fmt.Sprintf("Number: %d", e.Track.Number),
+			"Length: " + e.Track.Length.String(),
+		}
+	}
+
+	entries, err := t.list(e.Path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var lines []string
+	for _, e := range entries {
+		if e.Dir {
+			lines = append(lines, e.Name()+"/")
+		} else {
+			lines = append(lines, e.Name())
+		}
+	}
+
+	return lines
+}
+
+func (t *tui) nowPlaying() string {
+	s := t.status
+	if s.State == chubby.StateStopped {
+		return "Stopped"
+	}
+
+	return fmt.Sprintf("%s - %s (%s, %d)", s.Track.Artist,
+		s.Track.Title, s.Track.Album, s.Track.Year)
+}
+
+func (t *tui) progress(w int) string {
+	s := t.status
+	info := fmt.Sprintf(" vol %d ", s.Volume)
+	if s.State != chubby.StateStopped {
+		info = fmt.Sprintf(" %s/%s  %d/%d  %s%s",
+			formatSeconds(t.pos), formatSeconds(t.length),
+			s.PlaylistPos+1, s.Playlist.Length, s.State, info)
+	}
+
+	return " " + progressBar(t.pos, t.length, w-len(info)-1) + info
+}
+
+// progressBar renders text progress bar of the given width.
+func progressBar(pos int, length int, width int) string {
+	if width < 3 {
+		return ""
+	}
+	n := 0
+	if length > 0 {
+		n = min(pos, length) * (width - 2) / length
+	}
+
+	return "[" + strings.Repeat("=", n) +
+		strings.Repeat(" ", width-2-n) + "]"
+}
+
+// fit truncates or pads string with spaces to the given width.
+func fit(s string, width int) string {
+	rs := []rune(s)
+	if len(rs) > width {
+		if width < 1 {
+			return ""
+		}
+		return string(rs[:width-1]) + "…"
+	}
+
+	return s + strings.Repeat(" ", width-len(rs))
+}
+
+// readKey reads a single key press. Special keys are returned as
+// negative key codes.
+func readKey(in *bufio.Reader) (rune, error) {
+	r, _, err := in.ReadRune()
+	if err != nil || r != 27 {
+		return r, err
+	}
+
+	switch readEscape(in) {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	case '5':
+		return keyPgUp, nil
+	case '6':
+		return keyPgDown, nil
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/vchimishuk/chubby"
+)
+
+// vfsEntry is a single VFS directory listing item.
+type vfsEntry struct {
+	Dir   bool
+	Path  string
+	Track chubby.Track
+}
+
+func (e vfsEntry) Name() string {
+	return path.Base(e.Path)
+}
+
+// Current VFS directory relative paths are resolved against.
+var workDir string = "/"
+
+// vfsPath converts VFS path relative to the current directory
+// into absolute one.
+func vfsPath(p string) string {
+	if path.IsAbs(p) {
+		return p
+	}
+	abs := path.Join(workDir, p)
+	if strings.HasSuffix(p, "/") && abs != "/" {
+		abs += "/"
+	}
+
+	return abs
+}
+
+// listDir returns contents of the VFS directory.
+func listDir(ch *chubby.Chubby, dir string) ([]vfsEntry, error) {
+	entries, err := ch.List(dir)
+	if err != nil {
+		return nil, notFound(err, dir)
+	}
+
+	var res []vfsEntry
+	for _, e := range entries {
+		if e.IsDir() {
+			res = append(res, vfsEntry{
+				Dir:  true,
+				Path: e.Dir().Path,
+			})
+		} else {
+			res = append(res, vfsEntry{
+				Dir:   false,
+				Path:  e.Track().Path,
+				Track: e.Track(),
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// playPath starts playing the VFS track or directory.
+func playPath(ch *chubby.Chubby, p string) error {
+	return notFound(ch.Play(p), p)
+}
+
+// ambiguousPathError is returned when fuzzy path matches several
+// VFS entries and user cannot be asked to pick one.
+type ambiguousPathError struct {
+	Query string
+	Paths []string
+}
+
+func (e *ambiguousPathError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ambiguous path: %s", e.Query)
+	for _, p := range e.Paths {
+		fmt.Fprintf(&b, "\n  %s", p)
+	}
+
+	return b.String()
+}
+
+// resolvePath resolves path given as a list of words into existing VFS
+// path. Every path component is matched against entries of the
+// corresponding directory level ignoring case and diacritics, so
+// "zz xxx" resolves to "/ZZ Top/1999 - XXX". Exact name matches take
+// precedence over prefix matches, which take precedence over substring
+// ones. If several paths match user is asked to pick one of them on
+// a terminal, otherwise ambiguousPathError is returned.
+func resolvePath(env *Env, ch *chubby.Chubby, words []string) (string,
+	error) {
+
+	query := strings.Join(words, " ")
+	p := vfsPath(path.Join(words...))
+	if p == "/" {
+		return p, nil
+	}
+	comps := strings.Split(strings.Trim(p, "/"), "/")
+
+	cands := []string{"/"}
+	for i, c := range comps {
+		last := i == len(comps)-1
+		c = fold(c)
+		var next []string
+		best := 0
+		for _, dir := range cands {
+			entries, err := listDir(ch, dir)
+			if err != nil {
+				return "", err
+			}
+			for _, e := range entries {
+				if !e.Dir && !last {
+					continue
+				}
+				r := nameRank(fold(e.Name()), c)
+				if r > best {
+					best = r
+					next = next[:0]
+				}
+				if r > 0 && r == best {
+					next = append(next, e.Path)
+				}
+			}
+		}
+		if len(next) == 0 {
+			return "", &notFoundError{"path not found: " + query}
+		}
+		cands = next
+	}
+
+	if len(cands) == 1 {
+		return cands[0], nil
+	}
+
+	return pickPath(env, query, cands)
+}
+
+// nameRank returns how good name matches the word: 3 for exact match,
+// 2 for prefix, 1 for substring and 0 if name does not match at all.
+func nameRank(name string, word string) int {
+	switch {
+	case name == word:
+		return 3
+	case strings.HasPrefix(name, word):
+		return 2
+	case strings.Contains(name, word):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// pickPath asks user to choose one of the paths with a numbered menu.
+func pickPath(env *Env, query string, paths []string) (string, error) {
+	if !env.CanPrompt() {
+		return "", &ambiguousPathError{query, paths}
+	}
+	if t := env.deadline; t != nil {
+		// Time user takes to choose is not limited.
+		if !t.Stop() {
+			return "", errTimeout
+		}
+		defer t.Reset(env.Settings.TimeoutDuration())
+	}
+
+	for i, p := range paths {
+		env.Printf("%3d) %s\n", i+1, p)
+	}
+	for {
+		env.Printf("Select [1-%d]: ", len(paths))
+		line, err := readInputLine()
+		if err != nil || line == "" {
+			return "", errors.New("no path selected")
+		}
+		n, err := strconv.Atoi(line)
+		if err == nil && n >= 1 && n <= len(paths) {
+			return paths[n-1], nil
+		}
+	}
+}
+
+// readInputLine reads a single line from the standard input. Input is
+// read byte by byte to leave the rest of it for the subsequent readers.
+func readInputLine() (string, error) {
+	var b strings.Builder
+	buf := make([]byte, 1)
+	for {
+		_, err := os.Stdin.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		if buf[0] == '\n' {
+			return strings.TrimSpace(b.String()), nil
+		}
+		b.WriteByte(buf[0])
+	}
+}
@@ -44,7 +44,7 @@ func (c VolumeCommand) Args() (int, int) {
 	return 1, 1
 }
 
-func (c VolumeCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string) error {
+func (c VolumeCommand) Exec(env *Env, ch *chubby.Chubby, opts opt.Options, args []string) error {
 	var vols string = args[0]
 	var vol int
 	var mode chubby.VolumeMode = chubby.VolumeModeAbs
@@ -69,5 +69,5 @@ func (c VolumeCommand) Exec(ch *chubby.Chubby, opts opt.Options, args []string)
 		}
 	}
 
-	return ch.Volume(vol, mode)
+	return rejected(ch.Volume(vol, mode))
 }
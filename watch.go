@@ -0,0 +1,194 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/opt"
+)
+
+// statusWatch keeps player status up to date between events.
+type statusWatch struct {
+	stream       *eventStream
+	disconnected bool
+	status       chubby.Status
+	// Current track position and length in seconds.
+	pos    int
+	length int
+}
+
+// watchStatus prints player status every time it changes until
+// interrupted. If standard output is a terminal status is shown as
+// a single line which is redrawn in place and track position is
+// advanced locally every second. Otherwise, status is printed with the
+// template, as JSON or as a text line on every change.
+func watchStatus(env *Env, ch *chubby.Chubby, opts opt.Options,
+	t *template.Template) error {
+
+	stream, err := openEventStream(env.Settings, ch, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	fd, term := env.Terminal()
+	live := t == nil && env.Output != OutputJSON && term
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	if winchSignal != nil {
+		signal.Notify(sigs, winchSignal)
+	}
+	defer signal.Stop(sigs)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	w := &statusWatch{stream: stream}
+	if err := w.refresh(); err != nil {
+		return err
+	}
+	changed := true
+	for {
+		if live {
+			w.draw(env, fd)
+		} else if changed {
+			if err := w.print(env, t); err != nil {
+				return err
+			}
+		}
+		changed = false
+
+		select {
+		case e := <-stream.C:
+			if e == nil {
+				if live {
+					env.Println()
+				}
+				return errConnLost
+			}
+			if e.Event() == EventDisconnected {
+				w.disconnected = true
+			} else if err := w.refresh(); err != nil {
+				return err
+			}
+			changed = true
+		case <-ticker.C:
+			if !w.disconnected &&
+				w.status.State == chubby.StatePlaying &&
+				w.pos < w.length {
+				w.pos++
+			}
+		case sig := <-sigs:
+			if sig != winchSignal {
+				if live {
+					env.Println()
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// refresh requests current status. Watch is marked as disconnected if
+// the server is not available.
+func (w *statusWatch) refresh() error {
+	s, ok, err := w.stream.Status()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		w.disconnected = true
+		return nil
+	}
+	w.disconnected = false
+	w.status = s
+	w.pos = seconds(s.TrackPos)
+	w.length = seconds(s.Track.Length)
+
+	return nil
+}
+
+func (w *statusWatch) draw(env *Env, fd int) {
+	width, _, err := termSize(fd)
+	if err != nil || width == 0 {
+		width = 80
+	}
+	// Leave the last column empty to prevent line wrap.
+	env.Print("\r" + w.line(width-1) + "\x1b[K")
+}
+
+func (w *statusWatch) print(env *Env, t *template.Template) error {
+	if w.disconnected && (t != nil || env.Output == OutputJSON) {
+		// Status is unknown, nothing to print.
+		return nil
+	}
+	if t != nil {
+		return printTemplate(env.Stdout, t, w.status)
+	}
+	if env.Output == OutputJSON {
+		return env.PrintJSON(newJSONStatus(w.status))
+	}
+	env.Println(w.line(0))
+
+	return nil
+}
+
+// line returns status line of the given width. Progress bar is included
+// and the line is truncated or padded only if width is positive.
+func (w *statusWatch) line(width int) string {
+	s := w.status
+	if w.disconnected || s.State == chubby.StateStopped {
+		l := fmt.Sprintf("%s  vol %d", s.State, s.Volume)
+		if w.disconnected {
+			l = EventDisconnected
+		}
+		if width > 0 {
+			l = fit(l, width)
+		}
+		return l
+	}
+
+	track := fmt.Sprintf("%s - %s", s.Track.Artist, s.Track.Title)
+	info := fmt.Sprintf(" %s/%s  %d/%d  %s  vol %d",
+		formatSeconds(w.pos), formatSeconds(w.length),
+		s.PlaylistPos+1, s.Playlist.Length, s.State, s.Volume)
+	if width <= 0 {
+		return track + " " + info
+	}
+
+	rest := width - utf8.RuneCountInString(info)
+	bar := ""
+	if bw := min(32, rest/3); bw >= 10 {
+		bar = " " + progressBar(w.pos, w.length, bw)
+		rest -= bw + 1
+	}
+	if rest < 1 {
+		return fit(track+info, width)
+	}
+
+	return fit(track, rest) + bar + info
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// configHome returns XDG base directory for user configuration files.
+func configHome() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// cacheHome returns XDG base directory for user cache files.
+func cacheHome() string {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// dataHome returns XDG base directory for user data files.
+func dataHome() string {
+	return xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// stateHome returns XDG base directory for user state files.
+func stateHome() string {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// runtimeHome returns XDG base directory for user runtime files, like
+// sockets. Per-user temporary directory is used if it is not set.
+func runtimeHome() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("chubc-%d", os.Getuid()))
+}
+
+// runtimeDir returns directory of the daemon sockets and status files.
+func runtimeDir() string {
+	return filepath.Join(runtimeHome(), "chubc")
+}
+
+// checkRuntimeDir returns error if runtime directory can be accessed by
+// other users. Per-user temporary directory is checked as well, since
+// it can be created by another user in advance to intercept daemon
+// connections.
+func checkRuntimeDir() error {
+	dirs := []string{runtimeDir()}
+	if !filepath.IsAbs(os.Getenv("XDG_RUNTIME_DIR")) {
+		dirs = append(dirs, runtimeHome())
+	}
+	for _, d := range dirs {
+		if err := checkPrivateDir(d); err != nil {
+			return fmt.Errorf("insecure runtime directory: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func xdgDir(env string, def string) string {
+	dir := os.Getenv(env)
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), def)
+	}
+
+	return filepath.Join(home, def)
+}
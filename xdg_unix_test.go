@@ -0,0 +1,102 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubc.
+//
+// Chubc is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chubc is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chubc. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPrivateDir(t *testing.T) {
+	dir := t.TempDir()
+	mkdir := func(name string, mode os.FileMode) string {
+		p := filepath.Join(dir, name)
+		if err := os.Mkdir(p, mode); err != nil {
+			t.Fatal(err)
+		}
+		// Mode is not affected by umask.
+		if err := os.Chmod(p, mode); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	private := mkdir("private", 0700)
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(private, link); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{private, true},
+		{mkdir("shared", 0777), false},
+		{mkdir("group", 0750), false},
+		{link, false},
+		{file, false},
+		{filepath.Join(dir, "missing"), false},
+	}
+	for _, test := range tests {
+		err := checkPrivateDir(test.path)
+		if (err == nil) != test.ok {
+			t.Errorf("%s: unexpected result: %v", test.path, err)
+		}
+	}
+}
+
+func TestCheckRuntimeDir(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	t.Setenv("TMPDIR", tmp)
+	home := filepath.Join(tmp, fmt.Sprintf("chubc-%d", os.Getuid()))
+	if runtimeHome() != home {
+		t.Fatalf("expected %s, got %s", home, runtimeHome())
+	}
+
+	if err := os.MkdirAll(runtimeDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRuntimeDir(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	// Per-user temporary directory created by someone else.
+	if err := os.Chmod(home, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRuntimeDir(); err == nil {
+		t.Errorf("shared %s is not detected", home)
+	}
+
+	// XDG_RUNTIME_DIR itself is trusted.
+	t.Setenv("XDG_RUNTIME_DIR", home)
+	if err := os.MkdirAll(runtimeDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRuntimeDir(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}